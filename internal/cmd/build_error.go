@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BuildFailure records a single tmux command that failed while building part of a session
+type BuildFailure struct {
+	// Element names the part of the config that failed, e.g. `window "vim" split 2`
+	Element string
+	Err     error
+}
+
+// Error implements error
+func (f BuildFailure) Error() string {
+	return f.Element + ": " + f.Err.Error()
+}
+
+// Unwrap allows errors.Is/As to inspect the underlying error
+func (f BuildFailure) Unwrap() error {
+	return f.Err
+}
+
+// BuildError aggregates every failure encountered while building a single tmux session
+type BuildError struct {
+	SessionId string
+	Failures  []BuildFailure
+	// Aborted will be set if the build was stopped and the session killed after the first failure
+	Aborted bool
+}
+
+// Error implements error
+func (e *BuildError) Error() string {
+	var buf strings.Builder
+
+	fmt.Fprintf(&buf, "failed to build session %q", e.SessionId)
+	if e.Aborted {
+		buf.WriteString(" (aborted)")
+	}
+	buf.WriteString(":")
+
+	for _, f := range e.Failures {
+		buf.WriteString("\n  " + f.Error())
+	}
+
+	return buf.String()
+}
+
+// Unwrap allows errors.Is/As to inspect the individual failures
+func (e *BuildError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		errs = append(errs, f)
+	}
+
+	return errs
+}
+
+// failed reports if any failures have been recorded
+func (e *BuildError) failed() bool {
+	return len(e.Failures) > 0
+}
+
+// sessionElement names the session level config element
+const sessionElement = "session"
+
+// windowElement names a window within the config
+func windowElement(title string) string {
+	return fmt.Sprintf("window %q", title)
+}
+
+// splitElement names a split within a window, splits are numbered by their pane index
+func splitElement(title string, split int) string {
+	return fmt.Sprintf("%s split %d", windowElement(title), split)
+}
+
+// hookElement names a lifecycle hook
+func hookElement(name string) string {
+	return fmt.Sprintf("hook %q", name)
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	convertFlagIcl      bool
+	convertFlagJson     bool
+	convertFlagYaml     bool
+	convertFlagOutput   string
+	convertFlagForce    bool
+	convertFlagComments bool
+)
+
+// Convert rewrites a config in another of the supported formats
+func Convert() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert [path]",
+		Short: "Convert an automux config between the icl, json and yaml formats",
+		Long: `Convert an automux config between the icl, json and yaml formats
+
+The config is converted as it is written, includes, variables and sub session configs are kept
+as they are rather than being merged in`,
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         convertCmd,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVar(&convertFlagIcl, "icl", false, "Output the config in icl format")
+	cmd.Flags().BoolVar(&convertFlagJson, "json", false, "Output the config in json format")
+	cmd.Flags().BoolVar(&convertFlagYaml, "yaml", false, "Output the config in yaml format")
+	cmd.Flags().StringVarP(
+		&convertFlagOutput,
+		"output",
+		"o",
+		"",
+		"Write the config to the given file rather than stdout\nThe format is taken from the file extension",
+	)
+	cmd.Flags().BoolVarP(&convertFlagForce, "force", "f", false, "Overwrite the output file if it already exists")
+	cmd.Flags().BoolVar(
+		&convertFlagComments,
+		"comments",
+		false,
+		"Keep the comments above each field when converting between icl and yaml",
+	)
+
+	return cmd
+}
+
+func convertCmd(cmd *cobra.Command, args []string) error {
+	configPath, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		configPath = args[0]
+	}
+
+	path, err := config.Find(configPath, !flagNoGlobal)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no automux config found at %s", configPath)
+		}
+
+		return err
+	}
+
+	var format string
+	if convertFlagIcl {
+		format = config.DefaultPath
+	} else if convertFlagJson {
+		format = config.JsonPath
+	} else if convertFlagYaml {
+		format = config.YamlPath
+	}
+
+	if format == "" && convertFlagOutput == "" {
+		return errors.New("an output format is required, use --icl, --json, --yaml or --output")
+	}
+
+	target, err := outputFormat(format, convertFlagOutput)
+	if err != nil {
+		return err
+	}
+
+	data, err := config.Convert(path, target, convertFlagComments)
+	if err != nil {
+		return errors.New("!! invalid automux config !!\n " + err.Error())
+	}
+
+	if convertFlagOutput == "" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+
+	if _, err := os.Stat(convertFlagOutput); err == nil && !convertFlagForce {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", convertFlagOutput)
+	}
+
+	return os.WriteFile(convertFlagOutput, data, 0644)
+}
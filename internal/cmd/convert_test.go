@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// t_runConvert runs the convert command against the single session example
+func t_runConvert(t *testing.T, args ...string) (string, error) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var out bytes.Buffer
+
+	c := Convert()
+	c.SetArgs(append([]string{"../../_examples/single_session"}, args...))
+	c.SetOut(&out)
+	c.SetErr(&out)
+
+	err := c.Execute()
+
+	return out.String(), err
+}
+
+func TestConvertCmdStdout(t *testing.T) {
+	out, err := t_runConvert(t, "--yaml")
+	require.Nil(t, err)
+	require.Contains(t, out, "session_id: my-single-session\n")
+	require.NotContains(t, out, "null")
+}
+
+func TestConvertCmdOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".automux.json")
+
+	_, err := t_runConvert(t, "-o", path)
+	require.Nil(t, err)
+
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Contains(t, string(data), `"session_id": "my-single-session"`)
+
+	_, err = t_runConvert(t, "-o", path)
+	require.ErrorContains(t, err, "already exists")
+
+	_, err = t_runConvert(t, "-o", path, "--force")
+	require.Nil(t, err)
+}
+
+func TestConvertCmdNoFormat(t *testing.T) {
+	_, err := t_runConvert(t)
+	require.ErrorContains(t, err, "an output format is required")
+}
+
+func TestConvertCmdFormatConflict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".automux.yml")
+
+	_, err := t_runConvert(t, "--json", "-o", path)
+	require.EqualError(t, err, "--json does not match the extension of "+path)
+
+	_, err = os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,322 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFlagJson   bool
+	exportFlagYaml   bool
+	exportFlagOutput string
+	exportFlagRoot   string
+	exportFlagForce  bool
+	exportFlagSocket socketFlags
+)
+
+// Export generates an automux config from a running tmux session
+func Export() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export [session]",
+		Short: "Generate an automux config from the layout of a running tmux session",
+		Long: `Generate an automux config from the layout of a running tmux session
+
+If no session is given the session automux is being run from is exported`,
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         exportCmd,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVar(&exportFlagJson, "json", false, "Output the config in json format")
+	cmd.Flags().BoolVar(&exportFlagYaml, "yaml", false, "Output the config in yaml format")
+	cmd.Flags().StringVarP(
+		&exportFlagOutput,
+		"output",
+		"o",
+		"",
+		"Write the config to the given file rather than stdout\nThe format is taken from the file extension",
+	)
+	cmd.Flags().StringVar(
+		&exportFlagRoot,
+		"root",
+		"",
+		"Directory that paths in the config are made relative to (default the sessions start directory)",
+	)
+	cmd.Flags().BoolVarP(&exportFlagForce, "force", "f", false, "Overwrite the output file if it already exists")
+	exportFlagSocket.register(cmd)
+
+	return cmd
+}
+
+func exportCmd(cmd *cobra.Command, args []string) error {
+	var (
+		client  = tmuxClient(cmd, false)
+		session config.Session
+	)
+
+	session.SocketName = exportFlagSocket.name
+	session.SocketPath = exportFlagSocket.path
+
+	if len(args) == 1 {
+		session.SessionId = args[0]
+	} else if os.Getenv("TMUX") != "" {
+		name, err := tmux.CurrentSession(client, session)
+		if err != nil {
+			return err
+		}
+
+		session.SessionId = name
+	} else {
+		return errors.New("a session name is required when not running inside tmux")
+	}
+
+	sessions, err := tmux.ListSessions(client, session)
+	if err != nil {
+		return err
+	}
+
+	var info *tmux.SessionInfo
+	for i := range sessions {
+		if sessions[i].Name == session.SessionId {
+			info = &sessions[i]
+			break
+		}
+	}
+
+	if info == nil {
+		return fmt.Errorf("session %s is not running", session.SessionId)
+	}
+
+	root := info.Path
+	if exportFlagRoot != "" {
+		if root, err = filepath.Abs(exportFlagRoot); err != nil {
+			return err
+		}
+	}
+
+	windows, err := tmux.ListWindows(client, session)
+	if err != nil {
+		return err
+	}
+
+	panes, err := tmux.ListPanes(client, session)
+	if err != nil {
+		return err
+	}
+
+	if len(windows) == 0 || len(panes) == 0 {
+		// a running session always has a window so this means the output could not be read
+		return fmt.Errorf("failed to list the windows of session %s", session.SessionId)
+	}
+
+	conf := exportConfig(session.SessionId, root, windows, panes)
+	conf.SocketName = session.SocketName
+	conf.SocketPath = session.SocketPath
+
+	var format string
+	if exportFlagJson {
+		format = config.JsonPath
+	} else if exportFlagYaml {
+		format = config.YamlPath
+	}
+
+	path, err := outputFormat(format, exportFlagOutput)
+	if err != nil {
+		return err
+	}
+
+	data, err := config.Encode(conf, path)
+	if err != nil {
+		return err
+	}
+
+	if exportFlagOutput == "" {
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	}
+
+	if _, err := os.Stat(exportFlagOutput); err == nil && !exportFlagForce {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", exportFlagOutput)
+	}
+
+	return os.WriteFile(exportFlagOutput, data, 0644)
+}
+
+// outputFormat resolves the path whose extension picks the format of a generated config
+//
+// format is the path for the format flag that was given, if any. When both are set the format
+// has to match the extension of output so a file is never written in a format its name does not
+// match
+func outputFormat(format, output string) (string, error) {
+	switch {
+	case format == "" && output == "":
+		return config.DefaultPath, nil
+	case format == "":
+		return output, nil
+	case output == "":
+		return format, nil
+	}
+
+	if formatExt(format) != formatExt(output) {
+		return "", fmt.Errorf("%s does not match the extension of %s", formatFlags[formatExt(format)], output)
+	}
+
+	return output, nil
+}
+
+// formatFlags maps the extension of each config format to the flag that selects it
+var formatFlags = map[string]string{".automux": "--icl", ".json": "--json", ".yml": "--yaml"}
+
+// formatExt returns the extension of the path with the alternative yaml extension normalised
+func formatExt(path string) string {
+	if ext := filepath.Ext(path); ext != ".yaml" {
+		return ext
+	}
+
+	return ".yml"
+}
+
+// exportConfig converts the layout of a running session into a config
+//
+// automux builds each split by splitting the pane before it so each pane after the first is
+// exported as a split with its orientation and size worked out from its position relative to
+// the panes before it. Only the name of the foreground process is exported as exec, shells
+// are skipped
+func exportConfig(name, root string, windows []tmux.WindowInfo, panes []tmux.PaneInfo) *config.Config {
+	conf := &config.Config{
+		Version:        1,
+		SessionId:      name,
+		AttachExisting: true,
+	}
+
+	byWindow := make(map[int][]tmux.PaneInfo)
+	for _, pane := range panes {
+		byWindow[pane.WindowIndex] = append(byWindow[pane.WindowIndex], pane)
+	}
+
+	for _, info := range windows {
+		windowPanes := byWindow[info.Index]
+		if len(windowPanes) == 0 {
+			continue
+		}
+
+		var (
+			first     = windowPanes[0]
+			window    = config.Window{Title: info.Name}
+			windowDir = root
+		)
+
+		if dir := relativeDir(root, first.Path); dir != "" {
+			window.Directory = &dir
+			windowDir = first.Path
+		}
+
+		window.Exec = exportExec(first)
+
+		if info.Active && first.Active {
+			window.Focus = boolPtr(true)
+		}
+
+		for i := 1; i < len(windowPanes); i++ {
+			var (
+				pane  = windowPanes[i]
+				split = config.Split{Exec: exportExec(pane)}
+			)
+
+			if dir := relativeDir(windowDir, pane.Path); dir != "" {
+				split.Directory = &dir
+			}
+
+			vertical, size := splitGeometry(info, windowPanes[i-1], windowPanes[i:])
+			if vertical {
+				split.Vertical = boolPtr(true)
+			}
+			split.Size = &size
+
+			if info.Active && pane.Active {
+				split.Focus = boolPtr(true)
+			}
+
+			window.Splits = append(window.Splits, split)
+		}
+
+		conf.Windows = append(conf.Windows, window)
+	}
+
+	return conf
+}
+
+// splitGeometry works out how the pane before a split was divided to make room for it
+//
+// The split and every pane after it are assumed to have been carved out of the space it was
+// given when created, so their bounding box is used for the size
+func splitGeometry(window tmux.WindowInfo, prev tmux.PaneInfo, rest []tmux.PaneInfo) (bool, int) {
+	var (
+		left   = rest[0].Left
+		top    = rest[0].Top
+		right  = rest[0].Left + rest[0].Width
+		bottom = rest[0].Top + rest[0].Height
+	)
+
+	for _, pane := range rest[1:] {
+		left = min(left, pane.Left)
+		top = min(top, pane.Top)
+		right = max(right, pane.Left+pane.Width)
+		bottom = max(bottom, pane.Top+pane.Height)
+	}
+
+	// splits opened side by side share a top edge with the pane they were split from
+	if top == prev.Top && left > prev.Left {
+		return true, percent(right-left, window.Width)
+	}
+
+	return false, percent(bottom-top, window.Height)
+}
+
+// percent calculates the size of part as a rounded percentage of total
+//
+// The result is kept within 1-99 as that is the range a split size is allowed to be
+func percent(part, total int) int {
+	if total <= 0 {
+		return 50
+	}
+
+	return min(max(int(math.Round(float64(part)*100/float64(total))), 1), 99)
+}
+
+// relativeDir returns the path relative to root, paths outside of root are left absolute
+//
+// An empty string is returned if the path is root
+func relativeDir(root, path string) string {
+	if path == "" || path == root {
+		return ""
+	}
+
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	return rel
+}
+
+// exportExec returns the command running in the pane if it is not a shell
+func exportExec(pane tmux.PaneInfo) config.StringList {
+	if pane.Command == "" || isShell(pane.Command) {
+		return nil
+	}
+
+	return config.StringList{pane.Command}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportWindows = "0\t0\t200\t50\tbash\n1\t1\t200\t50\tcode\n"
+
+// exportPanes has a single shell pane in window 0 and a window 1 split into a full height left
+// pane with the right hand 30% split again top/bottom
+const exportPanes = "0\t0\t1\t200\t50\t0\t0\tzsh\t/tmp/proj\tbash\n" +
+	"1\t0\t0\t139\t50\t0\t0\tnvim\t/tmp/proj/api\tcode\n" +
+	"1\t1\t0\t60\t25\t140\t0\tzsh\t/tmp/proj\tcode\n" +
+	"1\t2\t1\t60\t24\t140\t26\tzsh\t/tmp\tcode\n"
+
+const exportExpectedICL = `version = 1
+session_id = "demo"
+attach_existing = true
+
+window "bash" {
+}
+
+window "code" {
+    exec = "nvim"
+    dir = "api"
+    split {
+        vertical = true
+        size = 30
+        dir = "/tmp/proj"
+    }
+    split {
+        size = 48
+        focus = true
+        dir = "/tmp"
+    }
+}
+`
+
+// TestExportCmd checks that the live session layout is converted into a config
+func TestExportCmd(t *testing.T) {
+	var (
+		b   bytes.Buffer
+		out bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = t_exportRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Export()
+	c.SetArgs([]string{"demo"})
+	c.SetOut(&out)
+
+	require.Nil(t, c.ExecuteContext(ctx), "ExportCmd")
+	assert.Equal(t, exportExpectedICL, out.String())
+}
+
+// TestExportCmdOutput checks that the config is written to file in the format of its extension
+func TestExportCmdOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.JsonPath)
+
+	for _, args := range [][]string{{"demo", "-o", path}, {"demo", "-o", path, "--force"}} {
+		ctx := context.WithValue(context.Background(), "logger", log.New(&bytes.Buffer{}, "", 0))
+		ctx = context.WithValue(ctx, "tmux", t_exportRecorder())
+
+		c := Export()
+		c.SetArgs(args)
+		require.Nil(t, c.ExecuteContext(ctx), "ExportCmd")
+
+		conf, err := config.Load(path, false, nil, false)
+		require.Nil(t, err)
+		require.Equal(t, "demo", conf.SessionId)
+		require.Len(t, conf.Windows, 2)
+	}
+
+	ctx := context.WithValue(context.Background(), "logger", log.New(&bytes.Buffer{}, "", 0))
+	ctx = context.WithValue(ctx, "tmux", t_exportRecorder())
+
+	c := Export()
+	c.SetArgs([]string{"demo", "-o", path})
+	require.NotNil(t, c.ExecuteContext(ctx), "existing file should not be overwritten")
+}
+
+func TestExportCmdNotRunning(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "logger", log.New(&bytes.Buffer{}, "", 0))
+	ctx = context.WithValue(ctx, "tmux", t_exportRecorder())
+
+	c := Export()
+	c.SetArgs([]string{"missing"})
+	require.NotNil(t, c.ExecuteContext(ctx))
+}
+
+var relativeDirChecks = []struct {
+	root     string
+	path     string
+	expected string
+}{
+	{"/tmp/proj", "/tmp/proj", ""},
+	{"/tmp/proj", "/tmp/proj/api", "api"},
+	{"/tmp/proj", "/tmp/proj/api/v1", "api/v1"},
+	{"/tmp/proj", "/tmp", "/tmp"},
+	{"/tmp/proj", "/tmp/project", "/tmp/project"},
+	{"/tmp/proj", "", ""},
+}
+
+func TestRelativeDir(t *testing.T) {
+	for _, check := range relativeDirChecks {
+		assert.Equal(t, check.expected, relativeDir(check.root, check.path), check.path)
+	}
+}
+
+// t_exportRecorder creates a recorder that reports the demo session as running
+func t_exportRecorder() *tmux.Recorder {
+	rec := tmux.NewRecorder("demo")
+	rec.Responses["list-sessions"] = "demo\t0\t2\t/tmp/proj\n"
+	rec.Responses["list-windows"] = exportWindows
+	rec.Responses["list-panes"] = exportPanes
+
+	return rec
+}
+
+// TestExportCmdFormatConflict checks that a format flag has to agree with the output extension
+func TestExportCmdFormatConflict(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, check := range []struct {
+		args []string
+		err  string
+	}{
+		{
+			[]string{"demo", "--json", "-o", filepath.Join(dir, "x.yml")},
+			"--json does not match the extension of " + filepath.Join(dir, "x.yml"),
+		},
+		{[]string{"demo", "--yaml", "-o", filepath.Join(dir, "x.yaml")}, ""},
+	} {
+		ctx := context.WithValue(context.Background(), "logger", log.New(&bytes.Buffer{}, "", 0))
+		ctx = context.WithValue(ctx, "tmux", t_exportRecorder())
+
+		c := Export()
+		c.SetArgs(check.args)
+
+		if err := c.ExecuteContext(ctx); check.err == "" {
+			require.Nil(t, err)
+		} else {
+			require.EqualError(t, err, check.err)
+		}
+	}
+
+	_, err := os.Stat(filepath.Join(dir, "x.yml"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+// TestExportCmdNoWindows checks that a session whose windows cannot be listed is not exported
+func TestExportCmdNoWindows(t *testing.T) {
+	rec := t_exportRecorder()
+	rec.Responses["list-windows"] = "0_1_80_24_editor\n"
+
+	ctx := context.WithValue(context.Background(), "logger", log.New(&bytes.Buffer{}, "", 0))
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	var out bytes.Buffer
+	c := Export()
+	c.SetArgs([]string{"demo"})
+	c.SetOut(&out)
+
+	require.EqualError(t, c.ExecuteContext(ctx), "failed to list the windows of session demo")
+	require.Empty(t, out.String())
+}
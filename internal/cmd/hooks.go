@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strings"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+)
+
+const (
+	beforeCreateHook = "before_create"
+	afterCreateHook  = "after_create"
+	onAttachHook     = "on_attach"
+	onKillHook       = "on_kill"
+)
+
+// runHooks runs the commands for a lifecycle hook in order stopping at the first one that fails
+func runHooks(client tmux.Client, session config.Session, commands []string) error {
+	for _, command := range commands {
+		if err := client.Shell(session, command); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runHook runs a lifecycle hook recording any failure against the build
+func (b *builder) runHook(name string, commands []string) bool {
+	if err := runHooks(b.client, b.session, commands); err != nil {
+		b.fail(hookElement(name), err)
+		return false
+	}
+
+	return true
+}
+
+// killHookSlots is the number of indexes tried for a session when the one derived from its id is
+// already used by another session
+const killHookSlots = 16
+
+// setKillHook registers the on_kill commands with tmux so they are run when the session closes
+//
+// tmux only runs session-closed hooks that are set globally so the hook is set under an index
+// unique to the session and checks the name of the closed session before running anything.
+// Sessions that are renamed out of the way by restart no longer match so do not trigger it. Once
+// it has run the hook removes itself so closed sessions do not leave hooks behind on the server
+func (b *builder) setKillHook() {
+	if b.session.Hooks == nil || len(b.session.Hooks.OnKill) == 0 {
+		return
+	}
+
+	dir, err := filepath.Abs(b.session.Directory)
+	if err != nil {
+		b.fail(hookElement(onKillHook), err)
+		return
+	}
+
+	index, err := b.killHookIndex()
+	if err != nil {
+		b.fail(hookElement(onKillHook), err)
+		return
+	}
+
+	script := []string{"cd " + shellQuote(dir)}
+	for _, key := range sortedKeys(b.session.Env) {
+		script = append(script, "export "+key+"="+shellQuote(b.session.Env[key]))
+	}
+	script = append(script, b.session.Hooks.OnKill...)
+
+	var (
+		name  = fmt.Sprintf("session-closed[%d]", index)
+		owner = killHookOwner(index)
+	)
+
+	// run-shell expands formats so any # in the script needs escaping
+	hook := fmt.Sprintf(
+		"if-shell -F %s { run-shell %s ; set-hook -gu %s ; set-option -gu %s }",
+		shellQuote("#{==:#{hook_session_name},"+b.session.SessionId+"}"),
+		shellQuote(strings.ReplaceAll(strings.Join(script, " && "), "#", "##")),
+		shellQuote(name),
+		owner,
+	)
+
+	if err := b.client.Run(b.session, "set-option", "-g", owner, b.session.SessionId); err != nil {
+		b.fail(hookElement(onKillHook), err)
+		return
+	}
+
+	if err := b.client.Run(b.session, "set-hook", "-g", name, hook); err != nil {
+		b.fail(hookElement(onKillHook), err)
+	}
+}
+
+// killHookIndex picks the index in the global session-closed hook array for the session
+//
+// The index is derived from the session id so that recreating the session replaces its hook
+// rather than adding another, indexes below 1000 are left for the users own hooks. The session
+// that owns each index is kept in a global user option so when two ids land on the same index the
+// next free one is used rather than replacing the other sessions hook, an index whose owner is no
+// longer running is treated as free
+func (b *builder) killHookIndex() (uint32, error) {
+	start := killHookStart(b.session.SessionId)
+
+	for i := uint32(0); i < killHookSlots; i++ {
+		index := 1000 + (start-1000+i)%900000
+
+		out, err := b.client.Output(b.session, "show-options", "-gqv", killHookOwner(index))
+		if err != nil {
+			return 0, err
+		}
+
+		owner := b.session
+		owner.SessionId = strings.TrimSpace(out)
+
+		if owner.SessionId == "" || owner.SessionId == b.session.SessionId || !b.client.SessionExists(owner) {
+			return index, nil
+		}
+	}
+
+	return 0, fmt.Errorf(
+		"session-closed[%d] and the %d indexes after it are used by other sessions",
+		start,
+		killHookSlots-1,
+	)
+}
+
+// killHookStart is the first index tried for the session in the global session-closed hook array
+func killHookStart(sessionId string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(sessionId))
+
+	return 1000 + h.Sum32()%900000
+}
+
+// killHookOwner is the global user option that records which session a kill hook index belongs to
+func killHookOwner(index uint32) string {
+	return fmt.Sprintf("@automux-on-kill-%d", index)
+}
+
+// shellQuote wraps the value in single quotes, this form is understood by both sh and tmux
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
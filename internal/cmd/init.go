@@ -84,7 +84,13 @@ func generateConfig(t, name string) ([]byte, error) {
 	name = strings.ReplaceAll(name, "\r", "")
 	name = strings.ReplaceAll(name, "\n", "")
 
-	if err = tmpl.Execute(&buf, struct{ SessionName string }{name}); err != nil {
+	data := struct {
+		SessionName string
+		// Schema points editors at the schema for json and yaml configs
+		Schema string
+	}{name, config.SchemaURL}
+
+	if err = tmpl.Execute(&buf, data); err != nil {
 		return nil, err
 	}
 
@@ -59,6 +59,7 @@ func TestInitCmd(t *testing.T) {
 }
 
 var expectedJsonConfig = `{
+  "$schema": "https://raw.githubusercontent.com/indeedhat/automux/master/configs/automux.schema.json",
   "version": 1,
   "session_id": "tester",
   "windows": [
@@ -109,7 +110,8 @@ func TestInitCmdWithJson(t *testing.T) {
 	require.Equal(t, expectedJsonConfig, string(data))
 }
 
-var expectedYamlConfig = `# config version
+var expectedYamlConfig = `# yaml-language-server: $schema=https://raw.githubusercontent.com/indeedhat/automux/master/configs/automux.schema.json
+# config version
 version: 1
 session_id: "tester"
 windows:
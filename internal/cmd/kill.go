@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+// shells contains the process names that are considered to be an idle pane
+var shells = map[string]bool{
+	"bash": true, "zsh": true, "fish": true, "sh": true, "dash": true, "ksh": true,
+	"mksh": true, "tcsh": true, "csh": true, "nu": true, "elvish": true, "xonsh": true,
+}
+
+var (
+	killFlagOnlySub bool
+	killFlagDryRun  bool
+	killFlagYes     bool
+	killFlagSocket  socketFlags
+)
+
+// Kill tears down the master session and every background session defined in the config
+func Kill() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "kill [dir]",
+		Aliases:      []string{"stop"},
+		Short:        "Kill the automux session and all of its background sessions",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         killCmd,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVar(&killFlagOnlySub, "only-sub", false, "Only kill the background sessions, leaving the master session running")
+	cmd.Flags().BoolVar(&killFlagDryRun, "dry-run", false, "Print the sessions that would be killed without killing them")
+	cmd.Flags().BoolVarP(&killFlagYes, "yes", "y", false, "Do not ask for confirmation when panes are running processes")
+	killFlagSocket.register(cmd)
+
+	return cmd
+}
+
+func killCmd(cmd *cobra.Command, args []string) error {
+	configPath, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		configPath = args[0]
+	}
+
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	killFlagSocket.apply(conf)
+
+	var (
+		client = tmuxClient(cmd, false)
+		out    = cmd.OutOrStdout()
+	)
+
+	sessions := runningSessions(client, conf, killFlagOnlySub)
+	if len(sessions) == 0 {
+		fmt.Fprintln(out, "no sessions running")
+		return nil
+	}
+
+	if killFlagDryRun {
+		for _, session := range sessions {
+			fmt.Fprintf(out, "would kill session %s\n", session.SessionId)
+		}
+
+		return nil
+	}
+
+	if !killFlagYes {
+		if busy := busyPanes(client, sessions); len(busy) > 0 {
+			fmt.Fprintln(out, "The following panes are still running processes:")
+			for _, pane := range busy {
+				fmt.Fprintln(out, "  "+pane)
+			}
+
+			if !confirm(out, "Kill sessions anyway? [y/N] ") {
+				return nil
+			}
+		}
+	}
+
+	var errs []error
+	for _, session := range sessions {
+		if err := tmux.Cmd(client, session, "kill-session"); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		fmt.Fprintf(out, "killed session %s\n", session.SessionId)
+	}
+
+	return errors.Join(errs...)
+}
+
+// runningSessions resolves the sessions defined in the config that are currently running
+//
+// The master session is always last so that running this from inside it does not kill the
+// client before the background sessions have been dealt with
+func runningSessions(client tmux.Client, conf *config.Config, onlySub bool) []config.Session {
+	var sessions []config.Session
+
+	for _, session := range conf.BackgroundSessions() {
+		if session.SessionId != "" && client.SessionExists(session) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	if master := conf.AsSession(); !onlySub && client.SessionExists(master) {
+		sessions = append(sessions, master)
+	}
+
+	return sessions
+}
+
+// busyPanes lists every pane within the sessions that is running something other than a shell
+//
+// A running session whose panes cannot be listed is included as well, there is no way to tell
+// that it is idle so the user should still be asked
+func busyPanes(client tmux.Client, sessions []config.Session) []string {
+	var busy []string
+
+	for _, session := range sessions {
+		panes, err := tmux.ListPanes(client, session)
+		if err != nil {
+			busy = append(busy, fmt.Sprintf("%s (failed to list panes: %s)", session.SessionId, err))
+			continue
+		}
+
+		if len(panes) == 0 {
+			busy = append(busy, fmt.Sprintf("%s (failed to list panes)", session.SessionId))
+			continue
+		}
+
+		for _, pane := range panes {
+			if isShell(pane.Command) {
+				continue
+			}
+
+			busy = append(busy, fmt.Sprintf(
+				"%s:%d.%d (%s) %s",
+				session.SessionId,
+				pane.WindowIndex,
+				pane.PaneIndex,
+				pane.WindowName,
+				pane.Command,
+			))
+		}
+	}
+
+	return busy
+}
+
+// isShell checks if the process name is a known shell or the users login shell
+func isShell(command string) bool {
+	command = strings.TrimPrefix(command, "-")
+
+	if shells[command] {
+		return true
+	}
+
+	if shell := os.Getenv("SHELL"); shell != "" && filepath.Base(shell) == command {
+		return true
+	}
+
+	return false
+}
+
+// confirm asks the user a yes/no question on stdin, anything other than yes is treated as no
+func confirm(out io.Writer, question string) bool {
+	fmt.Fprint(out, question)
+
+	input, err := readInput()
+	if err != nil && len(input) == 0 {
+		return false
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(string(input)))
+
+	return answer == "y" || answer == "yes"
+}
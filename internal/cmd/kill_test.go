@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var killChecks = []struct {
+	name     string
+	args     []string
+	stdin    string
+	panes    string
+	expected [][]string
+}{
+	{
+		"idle-panes",
+		nil,
+		"",
+		"0\t0\t1\t80\t24\t0\t0\tzsh\t/tmp\teditor\n",
+		[][]string{
+			{"kill-session", "-t", "my-overrides-subsession"},
+			{"kill-session", "-t", "my-multi-session"},
+		},
+	},
+	{
+		"only-sub",
+		[]string{"--only-sub"},
+		"",
+		"0\t0\t1\t80\t24\t0\t0\tzsh\t/tmp\teditor\n",
+		[][]string{{"kill-session", "-t", "my-overrides-subsession"}},
+	},
+	{"unlisted-panes-declined", nil, "n\n", "", nil},
+	{"unlisted-panes-confirmed", nil, "y\n", "unexpected output\n", [][]string{
+		{"kill-session", "-t", "my-overrides-subsession"},
+		{"kill-session", "-t", "my-multi-session"},
+	}},
+	{
+		"busy-confirmed",
+		nil,
+		"y\n",
+		"0\t0\t1\t80\t24\t0\t0\tnvim\t/tmp\teditor\n",
+		[][]string{
+			{"kill-session", "-t", "my-overrides-subsession"},
+			{"kill-session", "-t", "my-multi-session"},
+		},
+	},
+	{"busy-declined", nil, "n\n", "0\t0\t1\t80\t24\t0\t0\tnvim\t/tmp\teditor\n", nil},
+	{"busy-yes-flag", []string{"-y"}, "", "0\t0\t1\t80\t24\t0\t0\tnvim\t/tmp\teditor\n", [][]string{
+		{"kill-session", "-t", "my-overrides-subsession"},
+		{"kill-session", "-t", "my-multi-session"},
+	}},
+	{"dry-run", []string{"--dry-run"}, "", "0\t0\t1\t80\t24\t0\t0\tnvim\t/tmp\teditor\n", nil},
+}
+
+// TestKillCmd checks that the session graph from the config is killed, background sessions first
+func TestKillCmd(t *testing.T) {
+	testDir, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir("../../_examples/multi_session"))
+	defer os.Chdir(testDir)
+
+	for _, check := range killChecks {
+		t.Run(check.name, func(t *testing.T) {
+			restore := t_setStdin(t, check.stdin)
+			defer restore()
+
+			var (
+				b   bytes.Buffer
+				out bytes.Buffer
+				l   = log.New(&b, "", 0)
+				rec = tmux.NewRecorder("my-multi-session", "my-overrides-subsession")
+			)
+			rec.Responses["list-panes"] = check.panes
+
+			ctx := context.WithValue(context.Background(), "logger", l)
+			ctx = context.WithValue(ctx, "tmux", rec)
+
+			c := Kill()
+			c.SetArgs(check.args)
+			c.SetOut(&out)
+
+			require.Nil(t, c.ExecuteContext(ctx), "KillCmd")
+
+			var killed [][]string
+			for _, args := range rec.Args() {
+				if args[0] == "kill-session" {
+					killed = append(killed, args)
+				}
+			}
+
+			assert.Equal(t, check.expected, killed)
+		})
+	}
+}
+
+func TestIsShell(t *testing.T) {
+	assert.True(t, isShell("bash"))
+	assert.True(t, isShell("-zsh"))
+	assert.False(t, isShell("nvim"))
+}
+
+// t_setStdin replaces stdin with a file containing the given content
+func t_setStdin(t *testing.T, content string) func() {
+	oldStdin := os.Stdin
+
+	tmpfile, err := os.CreateTemp("", "stdin")
+	require.Nil(t, err, "create temp file")
+
+	_, err = tmpfile.WriteString(content)
+	require.Nil(t, err, "write temp file")
+
+	_, err = tmpfile.Seek(0, 0)
+	require.Nil(t, err, "seek")
+
+	os.Stdin = tmpfile
+
+	return func() {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+		os.Stdin = oldStdin
+	}
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+const (
+	statusAttached = "attached"
+	statusRunning  = "running"
+	statusMissing  = "missing"
+	statusInvalid  = "invalid"
+
+	sessionTypeMaster     = "master"
+	sessionTypeBackground = "background"
+)
+
+var (
+	lsFlagJson   bool
+	lsFlagSocket socketFlags
+)
+
+// Ls lists the sessions defined in the automux config along with their current state
+func Ls() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "ls [dir]",
+		Short:        "List the sessions defined by the automux config and whether they are running",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         lsCmd,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVar(&lsFlagJson, "json", false, "Output the session list as json")
+	lsFlagSocket.register(cmd)
+
+	return cmd
+}
+
+// sessionStatus describes the state of a single session defined in the config
+type sessionStatus struct {
+	Session string `json:"session"`
+	// Type is either master or background
+	Type string `json:"type"`
+	// Status is one of attached, running, missing or invalid
+	Status string `json:"status"`
+	// Windows is the number of windows open in the session if it is running or the number
+	// of windows in the config if not
+	Windows   int    `json:"windows"`
+	Directory string `json:"directory"`
+	// Server contains the tmux flags used to select the server the session lives on
+	Server string `json:"server,omitempty"`
+}
+
+func lsCmd(cmd *cobra.Command, args []string) error {
+	configPath, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		configPath = args[0]
+	}
+
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	lsFlagSocket.apply(conf)
+
+	statuses, err := sessionStatuses(tmuxClient(cmd, false), conf)
+	if err != nil {
+		return err
+	}
+
+	if lsFlagJson {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(statuses)
+	}
+
+	return writeStatusTable(cmd.OutOrStdout(), statuses)
+}
+
+// loadConfig loads the automux config at the given path for commands that require one to exist
+func loadConfig(path string) (*config.Config, error) {
+	conf, err := config.LoadAny(path, false, nil, !flagNoGlobal)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no automux config found at %s", path)
+		}
+
+		return nil, errors.New("!! invalid automux config !!\n " + err.Error())
+	}
+
+	return conf, nil
+}
+
+// sessionStatuses cross references the sessions in the config with those running on tmux
+func sessionStatuses(client tmux.Client, conf *config.Config) ([]sessionStatus, error) {
+	var (
+		statuses []sessionStatus
+		servers  = make(map[string]map[string]tmux.SessionInfo)
+	)
+
+	for i, session := range conf.AllSessions() {
+		status := sessionStatus{
+			Session:   session.SessionId,
+			Type:      sessionTypeBackground,
+			Status:    statusMissing,
+			Windows:   len(session.Windows),
+			Directory: session.Directory,
+			Server:    strings.Join(tmux.ServerArgs(session), " "),
+		}
+
+		if i == 0 {
+			status.Type = sessionTypeMaster
+		}
+
+		if session.SessionId == "" {
+			status.Status = statusInvalid
+			statuses = append(statuses, status)
+			continue
+		}
+
+		// sessions may be spread over multiple servers so only list each once
+		running, ok := servers[status.Server]
+		if !ok {
+			list, err := tmux.ListSessions(client, session)
+			if err != nil {
+				return nil, err
+			}
+
+			running = make(map[string]tmux.SessionInfo)
+			for _, info := range list {
+				running[info.Name] = info
+			}
+			servers[status.Server] = running
+		}
+
+		if info, ok := running[session.SessionId]; ok {
+			status.Status = statusRunning
+			if info.Attached {
+				status.Status = statusAttached
+			}
+
+			status.Windows = info.Windows
+			status.Directory = info.Path
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// writeStatusTable writes the session statuses as an aligned table
+func writeStatusTable(w io.Writer, statuses []sessionStatus) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SESSION\tTYPE\tSTATUS\tWINDOWS\tDIRECTORY\tSERVER")
+
+	for _, s := range statuses {
+		name := s.Session
+		if name == "" {
+			name = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%s\n", name, s.Type, s.Status, s.Windows, s.Directory, s.Server)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var lsListSessions = "my-multi-session\t1\t3\t/projects/multi\n" +
+	"my-overrides-subsession\t0\t2\t/projects/multi/with_overrides\n" +
+	"unrelated\t0\t1\t/tmp\n"
+
+var lsExpected = []sessionStatus{
+	{Session: "my-multi-session", Type: "master", Status: "attached", Windows: 3, Directory: "/projects/multi"},
+	{Session: "my-untouched-subsession", Type: "background", Status: "missing", Windows: 2, Directory: "./untouched/"},
+	{Session: "my-overrides-subsession", Type: "background", Status: "running", Windows: 2, Directory: "/projects/multi/with_overrides"},
+	{Session: "my-no-config-subsession", Type: "background", Status: "missing", Windows: 2, Directory: "./no_config_file/"},
+}
+
+// t_runLs runs the ls command from within the multi session example with a recording client
+func t_runLs(t *testing.T, args ...string) string {
+	testDir, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir("../../_examples/multi_session"))
+	defer os.Chdir(testDir)
+
+	var (
+		b   bytes.Buffer
+		out bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+	rec.Responses["list-sessions"] = lsListSessions
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Ls()
+	c.SetArgs(args)
+	c.SetOut(&out)
+
+	require.Nil(t, c.ExecuteContext(ctx), "LsCmd")
+
+	// all sessions share a server so it should only be queried once
+	require.Len(t, rec.Commands, 1)
+
+	return out.String()
+}
+
+func TestLsCmdJson(t *testing.T) {
+	var statuses []sessionStatus
+	require.Nil(t, json.Unmarshal([]byte(t_runLs(t, "--json")), &statuses))
+
+	assert.Equal(t, lsExpected, statuses)
+}
+
+func TestLsCmdTable(t *testing.T) {
+	lines := strings.Split(strings.TrimSpace(t_runLs(t)), "\n")
+
+	require.Len(t, lines, 5)
+	assert.Equal(t, []string{"SESSION", "TYPE", "STATUS", "WINDOWS", "DIRECTORY", "SERVER"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"my-multi-session", "master", "attached", "3", "/projects/multi"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"my-untouched-subsession", "background", "missing", "2", "./untouched/"}, strings.Fields(lines[2]))
+}
+
+func TestLsCmdNoConfig(t *testing.T) {
+	// the examples dir would be found by searching the parents of no_config_file
+	c := Ls()
+	c.SetArgs([]string{t.TempDir()})
+	c.SetErr(&bytes.Buffer{})
+
+	require.NotNil(t, c.ExecuteContext(context.Background()))
+}
@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain runs the tests with an empty home and config dir so that the users own global config
+// is never merged into the configs under test
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "automux-home")
+	if err != nil {
+		panic(err)
+	}
+
+	os.Setenv("HOME", home)
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	code := m.Run()
+
+	os.RemoveAll(home)
+	os.Exit(code)
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/picker"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pickFlagRoots  []string
+	pickFlagDepth  int
+	pickFlagIgnore []string
+	pickFlagDebug  bool
+	pickFlagSocket socketFlags
+)
+
+// Pick opens a fuzzy finder over the project directories and opens the chosen session
+func Pick() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "pick [dir]",
+		Short:        "Pick a project directory with a fuzzy finder and open or switch to its session",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         pickCmd,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().StringSliceVarP(&pickFlagRoots, "root", "r", nil, "Directory to scan for projects, can be given multiple times")
+	cmd.Flags().IntVar(&pickFlagDepth, "depth", 1, "How many levels below each root to scan for projects")
+	cmd.Flags().StringSliceVar(
+		&pickFlagIgnore,
+		"ignore",
+		[]string{".git", "node_modules"},
+		"Glob pattern for directory names to skip, can be given multiple times",
+	)
+	cmd.Flags().BoolVar(&pickFlagDebug, "debug", false, "print tmux commands rather than running them")
+	pickFlagSocket.register(cmd)
+
+	return cmd
+}
+
+func pickCmd(cmd *cobra.Command, args []string) error {
+	var dir string
+
+	if len(args) == 1 {
+		dir = args[0]
+	} else {
+		opts, err := pickOptions(cmd)
+		if err != nil {
+			return err
+		}
+
+		if len(opts.Roots) == 0 {
+			return errors.New("no project roots to scan, provide at least one with --root or in the global config")
+		}
+
+		items, err := picker.Scan(opts)
+		if err != nil {
+			return err
+		}
+
+		item, err := picker.Run(items)
+		if err != nil {
+			if errors.Is(err, picker.ErrCancelled) {
+				return nil
+			}
+
+			return err
+		}
+
+		dir = item.Path
+	}
+
+	conf, err := pickConfig(dir)
+	if err != nil {
+		return err
+	}
+
+	pickFlagSocket.apply(conf)
+
+	return launch(cmd, tmuxClient(cmd, pickFlagDebug), conf, false)
+}
+
+// pickOptions builds the scan options from the flags with the global configs picker block filling
+// in any that have not been given
+func pickOptions(cmd *cobra.Command) (picker.ScanOptions, error) {
+	opts := picker.ScanOptions{
+		Roots:  pickFlagRoots,
+		Depth:  pickFlagDepth,
+		Ignore: pickFlagIgnore,
+	}
+
+	if flagNoGlobal {
+		return opts, nil
+	}
+
+	global, err := config.LoadGlobal()
+	if err != nil {
+		return opts, err
+	}
+
+	if global == nil || global.Picker == nil {
+		return opts, nil
+	}
+
+	flags := cmd.Flags()
+	if !flags.Changed("root") {
+		opts.Roots = global.Picker.Roots
+	}
+	if !flags.Changed("depth") && global.Picker.Depth != nil {
+		opts.Depth = *global.Picker.Depth
+	}
+	if !flags.Changed("ignore") && global.Picker.Ignore != nil {
+		opts.Ignore = global.Picker.Ignore
+	}
+
+	return opts, nil
+}
+
+// pickConfig loads the automux config for the directory, falling back to a plain session
+// named after the directory if it does not have one
+func pickConfig(dir string) (*config.Config, error) {
+	if !config.Exists(config.Paths(dir)...) {
+		return &config.Config{
+			Directory:      dir,
+			SessionId:      plainSessionName(dir),
+			AttachExisting: true,
+		}, nil
+	}
+
+	conf, err := config.LoadAny(dir, false, nil, !flagNoGlobal)
+	if err != nil {
+		return nil, errors.New("!! invalid automux config !!\n " + err.Error())
+	}
+
+	return conf, nil
+}
+
+// plainSessionName builds a tmux safe session name from the directory name
+func plainSessionName(dir string) string {
+	name := filepath.Base(filepath.Clean(dir))
+
+	return strings.NewReplacer(".", "_", ":", "_", " ", "-").Replace(name)
+}
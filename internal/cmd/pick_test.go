@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pickChecks = []struct {
+	name     string
+	dir      string
+	existing []string
+	expected [][]string
+}{
+	{
+		"automux-dir",
+		"../../_examples/single_session",
+		[]string{"my-single-session"},
+		[][]string{{"attach", "-t", "my-single-session"}},
+	},
+	{
+		"plain-dir",
+		"../../_examples/multi_session/no_config_file/",
+		nil,
+		[][]string{
+			{"new-session", "-d", "-s", "no_config_file", "-c", "../../_examples/multi_session/no_config_file/"},
+			{"attach", "-t", "no_config_file"},
+		},
+	},
+	{
+		"plain-dir-existing",
+		"../../_examples/multi_session/no_config_file",
+		[]string{"no_config_file"},
+		[][]string{{"attach", "-t", "no_config_file"}},
+	},
+}
+
+// TestPickCmdDir checks that picking a directory directly opens the right session
+func TestPickCmdDir(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	for _, check := range pickChecks {
+		t.Run(check.name, func(t *testing.T) {
+			var (
+				b   bytes.Buffer
+				l   = log.New(&b, "", 0)
+				rec = tmux.NewRecorder(check.existing...)
+			)
+
+			ctx := context.WithValue(context.Background(), "logger", l)
+			ctx = context.WithValue(ctx, "tmux", rec)
+
+			c := Pick()
+			c.SetArgs([]string{check.dir})
+
+			require.Nil(t, c.ExecuteContext(ctx), "PickCmd")
+			assert.Equal(t, check.expected, rec.Args())
+		})
+	}
+}
+
+func TestPickCmdNoRoots(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	c := Pick()
+	c.SetArgs([]string{})
+	c.SetErr(&bytes.Buffer{})
+
+	require.NotNil(t, c.ExecuteContext(context.Background()))
+}
+
+// TestPickOptionsGlobal checks that the global configs picker block fills in the flags that are not given
+func TestPickOptionsGlobal(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "automux"), 0755))
+	require.Nil(t, os.WriteFile(
+		filepath.Join(dir, "automux", "config.yml"),
+		[]byte("version: 1\npicker:\n  roots: ~/projects\n  depth: 2\n"),
+		0644,
+	))
+
+	c := Pick()
+	require.Nil(t, c.ParseFlags([]string{"--depth", "3"}))
+
+	opts, err := pickOptions(c)
+	require.Nil(t, err)
+	assert.Equal(t, []string{"~/projects"}, opts.Roots)
+	assert.Equal(t, 3, opts.Depth)
+	assert.Equal(t, []string{".git", "node_modules"}, opts.Ignore)
+}
+
+func TestPlainSessionName(t *testing.T) {
+	assert.Equal(t, "my_dotted_project", plainSessionName("/home/user/my.dotted.project/"))
+	assert.Equal(t, "with-spaces", plainSessionName("with spaces"))
+}
@@ -3,22 +3,31 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"log"
 	"os"
+	"strings"
 
 	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
 	"github.com/spf13/cobra"
 )
 
-var printFlagDetached bool
+var (
+	printFlagDetached   bool
+	printFlagServerArgs bool
+	printFlagSocket     socketFlags
+)
 
 // PrintName just prints the session name to std out
 func PrintName() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "print-name",
 		Short: "Print the session name if the target directory is a automux directory",
-		Args:  cobra.MaximumNArgs(1),
-		RunE:  printCmd,
+		Long: `Print the session name if the target directory is a automux directory
+
+--server-args prints the tmux flags that select the server the session lives on instead, e.g.
+"-L work", nothing is printed for the default server`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: printCmd,
 	}
 
 	cmd.Flags().BoolVarP(
@@ -29,6 +38,13 @@ func PrintName() *cobra.Command {
 		"Run the automux session detached\n"+
 			"This will allow you to start an automux session from another session",
 	)
+	cmd.Flags().BoolVar(
+		&printFlagServerArgs,
+		"server-args",
+		false,
+		"Print the tmux flags for the server the session lives on rather than its name",
+	)
+	printFlagSocket.register(cmd)
 
 	return cmd
 }
@@ -43,17 +59,19 @@ func printCmd(cmd *cobra.Command, args []string) error {
 		configPath = args[0]
 	}
 
-	c, err := config.LoadAny(
-		configPath,
-		cmd.Context().Value("logger").(*log.Logger),
-		false,
-		printFlagDetached,
-	)
+	c, err := config.LoadAny(configPath, printFlagDetached, nil, !flagNoGlobal)
 	if err != nil {
 		return errors.New("!! invalid automux config !!\n " + err.Error())
 	}
 
-	fmt.Println(c.SessionId)
+	printFlagSocket.apply(c)
+
+	if printFlagServerArgs {
+		fmt.Fprintln(cmd.OutOrStdout(), strings.Join(tmux.ServerArgs(c.AsSession()), " "))
+		return nil
+	}
+
+	fmt.Fprintln(cmd.OutOrStdout(), c.SessionId)
 
 	return nil
 }
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var printNameChecks = []struct {
+	name     string
+	args     []string
+	expected string
+}{
+	{"default-server", nil, "my-multi-session\n"},
+	{"socket-name", []string{"-L", "work"}, "my-multi-session\n"},
+	{"server-args", []string{"--server-args"}, "\n"},
+	{"server-args-name", []string{"--server-args", "-L", "work"}, "-L work\n"},
+	{"server-args-path", []string{"--server-args", "-S", "/tmp/work.sock"}, "-S /tmp/work.sock\n"},
+}
+
+// TestPrintNameCmd checks that only the name is printed unless the server args are asked for
+func TestPrintNameCmd(t *testing.T) {
+	for _, check := range printNameChecks {
+		t.Run(check.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			c := PrintName()
+			c.SetArgs(append([]string{"../../_examples/multi_session"}, check.args...))
+			c.SetOut(&out)
+
+			require.Nil(t, c.Execute())
+			require.Equal(t, check.expected, out.String())
+		})
+	}
+}
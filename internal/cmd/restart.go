@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+// restartSuffix is appended to the name of a session while its replacement is being built
+const restartSuffix = "-automux-restart"
+
+var (
+	restartFlagAll      bool
+	restartFlagDetached bool
+	restartFlagSocket   socketFlags
+)
+
+// Restart rebuilds the automux session from the current config
+func Restart() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "restart [dir]",
+		Short:        "Kill and recreate the automux session from the current config",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         restartCmd,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVarP(&restartFlagAll, "all", "a", false, "Restart the background sessions as well as the master session")
+	cmd.Flags().BoolVarP(
+		&restartFlagDetached,
+		"detached",
+		"d",
+		false,
+		"Do not attach or switch to the session once it has been restarted",
+	)
+	restartFlagSocket.register(cmd)
+
+	return cmd
+}
+
+func restartCmd(cmd *cobra.Command, args []string) error {
+	configPath, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		configPath = args[0]
+	}
+
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	restartFlagSocket.apply(conf)
+
+	var (
+		client   = tmuxClient(cmd, false)
+		master   = conf.AsSession()
+		sessions = []config.Session{master}
+		errs     []error
+	)
+
+	if restartFlagAll {
+		for _, session := range conf.BackgroundSessions() {
+			if session.SessionId != "" {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+
+	for _, session := range sessions {
+		if err := restartSession(client, session); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	return enterSession(client, master, restartFlagDetached)
+}
+
+// restartSession replaces a running session with a freshly built one
+//
+// The old session is renamed out of the way rather than killed up front so that any attached
+// clients (including the one running automux) can be moved over to the new session, the
+// previously active window/pane is then restored if it still exists in the new layout
+func restartSession(client tmux.Client, session config.Session) error {
+	if !client.SessionExists(session) {
+		return createSession(client, session, false)
+	}
+
+	window, pane, err := tmux.ActivePane(client, session)
+	if err != nil {
+		return err
+	}
+
+	clients, err := tmux.ListClients(client, session)
+	if err != nil {
+		return err
+	}
+
+	old := session
+	old.SessionId += restartSuffix
+	if err := tmux.Cmd(client, session, "rename-session", old.SessionId); err != nil {
+		return err
+	}
+
+	buildErr := createSession(client, session, false)
+	if !client.SessionExists(session) {
+		// the new session never started so put the old one back as it was
+		if err := tmux.Cmd(client, old, "rename-session", session.SessionId); err != nil {
+			return errors.Join(buildErr, err)
+		}
+
+		return buildErr
+	}
+
+	for _, name := range clients {
+		if err := tmux.Cmd(client, session, "switch-client", "-c", name); err != nil {
+			return errors.Join(buildErr, err)
+		}
+	}
+
+	if err := tmux.Cmd(client, old, "kill-session"); err != nil {
+		return errors.Join(buildErr, err)
+	}
+
+	if err := restoreActivePane(client, session, window, pane); err != nil {
+		return errors.Join(buildErr, err)
+	}
+
+	return buildErr
+}
+
+// restoreActivePane selects the given window/pane if it exists within the session
+func restoreActivePane(client tmux.Client, session config.Session, window, pane int) error {
+	panes, err := tmux.ListPanes(client, session)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range panes {
+		if p.WindowIndex != window || p.PaneIndex != pane {
+			continue
+		}
+
+		target := session
+		target.SessionId = fmt.Sprintf("%s:%d.%d", session.SessionId, window, pane)
+
+		if err := tmux.Cmd(client, target, "select-window"); err != nil {
+			return err
+		}
+
+		return tmux.Cmd(client, target, "select-pane")
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var restartChecks = []struct {
+	name     string
+	args     []string
+	running  []string
+	panes    string
+	expected [][]string
+	// last contains the final commands that are expected to be run
+	last [][]string
+}{
+	{
+		"not-running",
+		[]string{"-d"},
+		nil,
+		"",
+		nil,
+		nil,
+	},
+	{
+		"restores-pane",
+		[]string{"-d"},
+		[]string{"my-multi-session"},
+		"1\t1\t1\t80\t24\t0\t0\tzsh\t/tmp\tsplits\n",
+		[][]string{
+			{"display-message", "-p", "-t", "my-multi-session", "#{window_index}\t#{pane_index}"},
+			{"list-clients", "-t", "my-multi-session", "-F", "#{client_name}"},
+			{"rename-session", "-t", "my-multi-session", "my-multi-session-automux-restart"},
+			{"switch-client", "-t", "my-multi-session", "-c", "/dev/pts/1"},
+			{"kill-session", "-t", "my-multi-session-automux-restart"},
+		},
+		[][]string{
+			{"select-window", "-t", "my-multi-session:1.1"},
+			{"select-pane", "-t", "my-multi-session:1.1"},
+		},
+	},
+	{
+		"pane-gone",
+		[]string{"-d"},
+		[]string{"my-multi-session"},
+		"0\t0\t1\t80\t24\t0\t0\tzsh\t/tmp\teditor\n",
+		[][]string{
+			{"display-message", "-p", "-t", "my-multi-session", "#{window_index}\t#{pane_index}"},
+			{"list-clients", "-t", "my-multi-session", "-F", "#{client_name}"},
+			{"rename-session", "-t", "my-multi-session", "my-multi-session-automux-restart"},
+			{"switch-client", "-t", "my-multi-session", "-c", "/dev/pts/1"},
+			{"kill-session", "-t", "my-multi-session-automux-restart"},
+		},
+		[][]string{
+			{"kill-session", "-t", "my-multi-session-automux-restart"},
+			{"list-panes", "-s", "-t", "my-multi-session", "-F", t_listPanesFormat},
+		},
+	},
+	{
+		"all",
+		[]string{"-d", "--all"},
+		[]string{"my-multi-session", "my-overrides-subsession"},
+		"",
+		[][]string{
+			{"display-message", "-p", "-t", "my-multi-session", "#{window_index}\t#{pane_index}"},
+			{"list-clients", "-t", "my-multi-session", "-F", "#{client_name}"},
+			{"rename-session", "-t", "my-multi-session", "my-multi-session-automux-restart"},
+			{"switch-client", "-t", "my-multi-session", "-c", "/dev/pts/1"},
+			{"kill-session", "-t", "my-multi-session-automux-restart"},
+			{"display-message", "-p", "-t", "my-overrides-subsession", "#{window_index}\t#{pane_index}"},
+			{"list-clients", "-t", "my-overrides-subsession", "-F", "#{client_name}"},
+			{"rename-session", "-t", "my-overrides-subsession", "my-overrides-subsession-automux-restart"},
+			{"switch-client", "-t", "my-overrides-subsession", "-c", "/dev/pts/1"},
+			{"kill-session", "-t", "my-overrides-subsession-automux-restart"},
+		},
+		nil,
+	},
+}
+
+const t_listPanesFormat = "#{window_index}\t#{pane_index}\t#{pane_active}\t#{pane_width}\t#{pane_height}\t#{pane_left}\t#{pane_top}" +
+	"\t#{pane_current_command}\t#{pane_current_path}\t#{window_name}"
+
+// TestRestartCmd checks that running sessions are swapped out for a fresh build
+func TestRestartCmd(t *testing.T) {
+	testDir, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir("../../_examples/multi_session"))
+	defer os.Chdir(testDir)
+
+	for _, check := range restartChecks {
+		t.Run(check.name, func(t *testing.T) {
+			var (
+				b   bytes.Buffer
+				l   = log.New(&b, "", 0)
+				rec = tmux.NewRecorder(check.running...)
+			)
+			rec.Responses["display-message"] = "1\t1\n"
+			rec.Responses["list-clients"] = "/dev/pts/1\n"
+			rec.Responses["list-panes"] = check.panes
+
+			ctx := context.WithValue(context.Background(), "logger", l)
+			ctx = context.WithValue(ctx, "tmux", rec)
+
+			c := Restart()
+			c.SetArgs(check.args)
+
+			require.Nil(t, c.ExecuteContext(ctx), "RestartCmd")
+
+			args := rec.Args()
+			require.NotEmpty(t, args)
+			if len(check.running) == 0 {
+				assert.Equal(t, "new-session", args[0][0])
+			}
+
+			assert.Equal(t, check.expected, t_restartCommands(args))
+
+			if check.last != nil {
+				require.GreaterOrEqual(t, len(args), len(check.last))
+				assert.Equal(t, check.last, args[len(args)-len(check.last):])
+			}
+		})
+	}
+}
+
+// t_restartCommands filters out the commands issued by createSession
+//
+// select-window/select-pane are also used when building the session so they are checked via last
+func t_restartCommands(args [][]string) [][]string {
+	var filtered [][]string
+
+	for _, a := range args {
+		switch a[0] {
+		case "display-message", "list-clients", "rename-session", "switch-client", "kill-session":
+			filtered = append(filtered, a)
+		}
+	}
+
+	return filtered
+}
+
+// TestRestartUnreadablePane checks that the running session is left alone when its active pane
+// cannot be read
+func TestRestartUnreadablePane(t *testing.T) {
+	testDir, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir("../../_examples/multi_session"))
+	defer os.Chdir(testDir)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder("my-multi-session")
+	)
+	rec.Responses["display-message"] = "1_1\n"
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Restart()
+	c.SetArgs([]string{"-d"})
+
+	err = c.ExecuteContext(ctx)
+	require.ErrorContains(t, err, `failed to read the active pane for my-multi-session from "1_1\n"`)
+	assert.Equal(t, [][]string{
+		{"display-message", "-p", "-t", "my-multi-session", "#{window_index}\t#{pane_index}"},
+	}, t_restartCommands(rec.Args()))
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// Schema prints the JSON Schema for the json and yaml config formats
+func Schema() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema for the json and yaml config formats",
+		Long: `Print the JSON Schema for the json and yaml config formats
+
+Configs created with init --json/--yaml already reference the published copy of the schema:
+` + config.SchemaURL,
+		Args:         cobra.NoArgs,
+		RunE:         schemaCmd,
+		SilenceUsage: true,
+	}
+}
+
+func schemaCmd(cmd *cobra.Command, args []string) error {
+	data, err := config.Schema()
+	if err != nil {
+		return err
+	}
+
+	_, err = cmd.OutOrStdout().Write(data)
+	return err
+}
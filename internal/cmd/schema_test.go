@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCmd(t *testing.T) {
+	var out bytes.Buffer
+
+	c := Schema()
+	c.SetOut(&out)
+	require.Nil(t, c.Execute())
+
+	expected, err := config.Schema()
+	require.Nil(t, err)
+	require.Equal(t, string(expected), out.String())
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// socketFlags holds the tmux server selection flags shared between commands
+type socketFlags struct {
+	name string
+	path string
+}
+
+// register adds the socket flags to the given command
+func (f *socketFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&f.name, "socket", "L", "", "Use the tmux server with the given socket name (tmux -L)")
+	cmd.Flags().StringVarP(&f.path, "socket-path", "S", "", "Use the tmux server at the given socket path (tmux -S)")
+}
+
+// apply overrides the tmux server selected by the config with the one given on the cli
+//
+// background sessions that inherit the master server will follow the override
+func (f *socketFlags) apply(conf *config.Config) {
+	if f.name == "" && f.path == "" {
+		return
+	}
+
+	conf.SocketName = f.name
+	conf.SocketPath = f.path
+}
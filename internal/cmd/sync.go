@@ -0,0 +1,351 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/spf13/cobra"
+)
+
+var (
+	syncFlagAll    bool
+	syncFlagDryRun bool
+	syncFlagYes    bool
+	syncFlagSocket socketFlags
+)
+
+// Sync adds any windows/splits that are in the config but missing from the running session
+func Sync() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:          "sync [dir]",
+		Short:        "Add windows and splits from the config that are missing from the running session",
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         syncCmd,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVarP(&syncFlagAll, "all", "a", false, "Sync the background sessions as well as the master session")
+	cmd.Flags().BoolVar(&syncFlagDryRun, "dry-run", false, "Print the changes that would be made without applying them")
+	cmd.Flags().BoolVarP(&syncFlagYes, "yes", "y", false, "Apply the changes without asking for confirmation")
+	syncFlagSocket.register(cmd)
+
+	return cmd
+}
+
+// syncPlan describes the changes required to bring a session in line with its config
+type syncPlan struct {
+	session config.Session
+	// create is set when the session is not running at all
+	create  bool
+	windows []windowChange
+	// notes contains differences that sync will not touch
+	notes []string
+}
+
+// windowChange describes the splits that need to be added to a window
+type windowChange struct {
+	window config.Window
+	// target is the pane that new splits will be split from, it is empty when the window itself
+	// needs creating
+	target string
+	// from is the index of the first split in the windows config that needs creating
+	from int
+}
+
+// empty checks if the plan has any changes to make
+func (p syncPlan) empty() bool {
+	return !p.create && len(p.windows) == 0
+}
+
+func syncCmd(cmd *cobra.Command, args []string) error {
+	configPath, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		configPath = args[0]
+	}
+
+	conf, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	syncFlagSocket.apply(conf)
+
+	var (
+		client   = tmuxClient(cmd, false)
+		out      = cmd.OutOrStdout()
+		sessions = []config.Session{conf.AsSession()}
+		plans    []syncPlan
+		pending  bool
+	)
+
+	if syncFlagAll {
+		for _, session := range conf.BackgroundSessions() {
+			if session.SessionId != "" {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+
+	for _, session := range sessions {
+		plan, err := planSync(client, session)
+		if err != nil {
+			return err
+		}
+
+		writeSyncPlan(out, plan)
+		plans = append(plans, plan)
+		pending = pending || !plan.empty()
+	}
+
+	if !pending || syncFlagDryRun {
+		return nil
+	}
+
+	if !syncFlagYes && !confirm(out, "Apply changes? [y/N] ") {
+		return nil
+	}
+
+	var errs []error
+	for _, plan := range plans {
+		if err := applySync(client, plan); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// planSync compares the running session against its config
+//
+// Windows are matched by title and are expected to have one pane plus one per split, anything
+// extra that is running is left alone
+func planSync(client tmux.Client, session config.Session) (syncPlan, error) {
+	plan := syncPlan{session: session}
+
+	if !client.SessionExists(session) {
+		plan.create = true
+		return plan, nil
+	}
+
+	panes, err := tmux.ListPanes(client, session)
+	if err != nil {
+		return plan, err
+	}
+
+	if len(panes) == 0 {
+		// a running session always has at least one pane, planning against nothing would add a
+		// copy of every window
+		return plan, fmt.Errorf("failed to list the panes of running session %s", session.SessionId)
+	}
+
+	var (
+		order   []int
+		running = make(map[int][]tmux.PaneInfo)
+		titles  = make(map[string][]int)
+	)
+
+	for _, pane := range panes {
+		if _, ok := running[pane.WindowIndex]; !ok {
+			order = append(order, pane.WindowIndex)
+			titles[pane.WindowName] = append(titles[pane.WindowName], pane.WindowIndex)
+		}
+
+		running[pane.WindowIndex] = append(running[pane.WindowIndex], pane)
+	}
+
+	matched := make(map[int]bool)
+	for _, window := range session.Windows {
+		if len(titles[window.Title]) == 0 {
+			plan.windows = append(plan.windows, windowChange{window: window})
+			continue
+		}
+
+		index := titles[window.Title][0]
+		titles[window.Title] = titles[window.Title][1:]
+		matched[index] = true
+
+		windowPanes := running[index]
+		if window.HasSplitTree() {
+			// there is no way to tell which pane in the tree is missing so nested splits can only be
+			// synced by adding the whole window
+			if expected := window.PaneCount(); len(windowPanes) < expected {
+				plan.notes = append(plan.notes, fmt.Sprintf(
+					"%s has %d panes but the config has %d, nested splits can only be added with new windows",
+					windowElement(window.Title),
+					len(windowPanes),
+					expected,
+				))
+			}
+
+			continue
+		}
+
+		for i, pane := range windowPanes {
+			if i > len(window.Splits) {
+				break
+			}
+
+			expected := syncPaneDirectory(session, window, i)
+			if expected != "" && pane.Path != "" && pane.Path != expected {
+				plan.notes = append(plan.notes, fmt.Sprintf(
+					"%s pane %d is in %s, the config expects %s",
+					windowElement(window.Title),
+					i,
+					pane.Path,
+					expected,
+				))
+			}
+		}
+
+		if len(windowPanes) > len(window.Splits) {
+			continue
+		}
+
+		last := windowPanes[len(windowPanes)-1]
+		plan.windows = append(plan.windows, windowChange{
+			window: window,
+			target: fmt.Sprintf("%s:%d.%d", session.SessionId, index, last.PaneIndex),
+			from:   len(windowPanes) - 1,
+		})
+	}
+
+	for _, index := range order {
+		if !matched[index] {
+			plan.notes = append(plan.notes, fmt.Sprintf(
+				"window %q is not in the config",
+				running[index][0].WindowName,
+			))
+		}
+	}
+
+	return plan, nil
+}
+
+// syncPaneDirectory resolves the absolute directory the given pane of a window is expected to be in
+//
+// pane 0 is the window itself, each split follows in order
+func syncPaneDirectory(session config.Session, window config.Window, pane int) string {
+	var dir string
+	if pane == 0 {
+		if window.Directory != nil {
+			dir = *window.Directory
+		}
+	} else {
+		dir = window.SplitDirectory(window.Splits[pane-1])
+	}
+
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(session.Directory, dir)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	return abs
+}
+
+// writeSyncPlan prints the changes sync is going to make to a session
+func writeSyncPlan(w io.Writer, plan syncPlan) {
+	if plan.empty() && len(plan.notes) == 0 {
+		fmt.Fprintf(w, "session %s is up to date\n", plan.session.SessionId)
+		return
+	}
+
+	fmt.Fprintf(w, "session %s:\n", plan.session.SessionId)
+
+	if plan.create {
+		fmt.Fprintf(w, "  + create session (%d windows)\n", len(plan.session.Windows))
+	}
+
+	for _, change := range plan.windows {
+		if change.target == "" {
+			fmt.Fprintf(w, "  + %s (%d panes)\n", windowElement(change.window.Title), change.window.PaneCount())
+			continue
+		}
+
+		for i := change.from; i < len(change.window.Splits); i++ {
+			fmt.Fprintf(w, "  + %s\n", splitElement(change.window.Title, i+1))
+		}
+	}
+
+	for _, note := range plan.notes {
+		fmt.Fprintf(w, "  ~ %s\n", note)
+	}
+}
+
+// applySync makes the changes in the plan without touching the existing windows or changing the
+// focused window/pane of any attached clients
+func applySync(client tmux.Client, plan syncPlan) error {
+	if plan.create {
+		return createSession(client, plan.session, false)
+	}
+
+	b := &builder{
+		client:     client,
+		session:    plan.session,
+		background: true,
+		err:        &BuildError{SessionId: plan.session.SessionId},
+	}
+
+	for _, change := range plan.windows {
+		if change.target == "" {
+			b.addWindow(change.window)
+			continue
+		}
+
+		// later splits are split from the last pane as they would be when the session is created
+		state := &splitTreeState{
+			window: change.window,
+			panes:  make(map[string]string),
+			number: change.from,
+			focus:  new(string),
+		}
+		b.splitTree(state, change.window.Splits[change.from:], change.target, change.window.Env)
+	}
+
+	if b.err.failed() {
+		return b.err
+	}
+
+	return nil
+}
+
+// addWindow opens a new window in the background along with all of its splits
+func (b *builder) addWindow(window config.Window) {
+	element := windowElement(window.Title)
+
+	args := []string{"new-window", "-n", window.Title}
+	if window.Directory != nil && *window.Directory != "" {
+		args = append(args, "-c", *window.Directory)
+	}
+	args = append(args, envArgs(window.Env)...)
+	args = b.processArgs(args, window.Run, window.RemainOnExit, "last-window")
+
+	root, ok := b.paneCmd(element, b.session.SessionId+":", args...)
+	if !ok {
+		return
+	}
+
+	b.registerPane(window.Title, root)
+	if b.waitFor(element, window.WaitFor) {
+		b.sendExec(element, root, window.Exec, window.ExecDelay)
+	}
+
+	// focus is ignored so that attached clients are left where they are
+	var focus string
+	b.processSplitTree(window, root, &focus)
+
+	// layouts rearrange every pane in the window so are only applied to new windows
+	b.applyLayout(window, root)
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var syncChecks = []struct {
+	name     string
+	args     []string
+	stdin    string
+	running  bool
+	panes    string
+	plan     string
+	expected [][]string
+}{
+	{
+		"up-to-date",
+		nil,
+		"",
+		true,
+		"0\t0\t1\t80\t24\t0\t0\tnvim\t{dir}\teditor\n1\t0\t1\t80\t24\t0\t0\tzsh\t{dir}\tcmd\n1\t1\t0\t80\t24\t0\t0\tzsh\t{dir}\tcmd\n",
+		"session my-multi-session is up to date\n",
+		nil,
+	},
+	{
+		"missing-window",
+		[]string{"-y"},
+		"",
+		true,
+		"0\t0\t1\t80\t24\t0\t0\tnvim\t{dir}\teditor\n2\t0\t1\t80\t24\t0\t0\tzsh\t{dir}\tscratch\n",
+		"session my-multi-session:\n" +
+			"  + window \"cmd\" (2 panes)\n" +
+			"  ~ window \"scratch\" is not in the config\n",
+		[][]string{
+			{"new-window", "-t", "my-multi-session:", "-P", "-F", "#{pane_id}", "-n", "cmd", "-d"},
+			{"split-window", "-t", "%1", "-P", "-F", "#{pane_id}", "-v", "-d"},
+		},
+	},
+	{
+		"missing-split",
+		nil,
+		"y\n",
+		true,
+		"0\t0\t1\t80\t24\t0\t0\tnvim\t{dir}\teditor\n3\t2\t1\t80\t24\t0\t0\tzsh\t{dir}\tcmd\n",
+		"session my-multi-session:\n  + window \"cmd\" split 1\nApply changes? [y/N] ",
+		[][]string{{"split-window", "-t", "my-multi-session:3.2", "-P", "-F", "#{pane_id}", "-v", "-d"}},
+	},
+	{
+		"moved-pane",
+		nil,
+		"",
+		true,
+		"0\t0\t1\t80\t24\t0\t0\tnvim\t{dir}\teditor\n1\t0\t1\t80\t24\t0\t0\tzsh\t/tmp\tcmd\n1\t1\t0\t80\t24\t0\t0\tzsh\t{dir}\tcmd\n",
+		"session my-multi-session:\n  ~ window \"cmd\" pane 0 is in /tmp, the config expects {dir}\n",
+		nil,
+	},
+	{
+		"declined",
+		nil,
+		"n\n",
+		true,
+		"0\t0\t1\t80\t24\t0\t0\tnvim\t{dir}\teditor\n",
+		"session my-multi-session:\n  + window \"cmd\" (2 panes)\nApply changes? [y/N] ",
+		nil,
+	},
+	{
+		"dry-run",
+		[]string{"--dry-run"},
+		"",
+		true,
+		"0\t0\t1\t80\t24\t0\t0\tnvim\t{dir}\teditor\n",
+		"session my-multi-session:\n  + window \"cmd\" (2 panes)\n",
+		nil,
+	},
+	{
+		"not-running",
+		[]string{"-y"},
+		"",
+		false,
+		"",
+		"session my-multi-session:\n  + create session (2 windows)\n",
+		[][]string{{"new-session", "-d", "-s", "my-multi-session", "-c", "{dir}"}},
+	},
+}
+
+// TestSyncCmd checks that only the parts of the config missing from the session are created
+func TestSyncCmd(t *testing.T) {
+	testDir, err := os.Getwd()
+	require.Nil(t, err)
+	require.Nil(t, os.Chdir("../../_examples/multi_session"))
+	defer os.Chdir(testDir)
+
+	dir, err := os.Getwd()
+	require.Nil(t, err)
+
+	// {dir} stands in for the directory of the config in the checks
+	withDir := func(s string) string {
+		return strings.ReplaceAll(s, "{dir}", dir)
+	}
+
+	for _, check := range syncChecks {
+		t.Run(check.name, func(t *testing.T) {
+			restore := t_setStdin(t, check.stdin)
+			defer restore()
+
+			var (
+				b   bytes.Buffer
+				out bytes.Buffer
+				l   = log.New(&b, "", 0)
+				rec = tmux.NewRecorder()
+			)
+			if check.running {
+				rec.Sessions["my-multi-session"] = true
+			}
+			rec.Responses["list-panes"] = withDir(check.panes)
+			rec.Responses["new-window"] = "%1\n"
+			rec.Responses["split-window"] = "%2\n"
+
+			ctx := context.WithValue(context.Background(), "logger", l)
+			ctx = context.WithValue(ctx, "tmux", rec)
+
+			c := Sync()
+			c.SetArgs(check.args)
+			c.SetOut(&out)
+
+			require.Nil(t, c.ExecuteContext(ctx), "SyncCmd")
+
+			var changes [][]string
+			for _, args := range rec.Args() {
+				switch args[0] {
+				case "new-session", "new-window", "split-window":
+					changes = append(changes, args)
+				}
+			}
+
+			if len(changes) > 1 && changes[0][0] == "new-session" {
+				changes = changes[:1]
+			}
+
+			for _, args := range check.expected {
+				for i := range args {
+					args[i] = withDir(args[i])
+				}
+			}
+
+			assert.Equal(t, withDir(check.plan), out.String())
+			assert.Equal(t, check.expected, changes)
+		})
+	}
+}
+
+// TestPlanSyncSplitTree checks that windows with nested splits are only ever added whole
+func TestPlanSyncSplitTree(t *testing.T) {
+	var (
+		right = "right"
+		tree  = []config.Split{{Name: &right, Splits: []config.Split{{}}}, {Target: &right}}
+		rec   = tmux.NewRecorder("tree")
+	)
+
+	session := config.Session{
+		SessionId: "tree",
+		Directory: "/tmp",
+		Windows:   []config.Window{{Title: "dev", Splits: tree}, {Title: "logs", Splits: tree}},
+	}
+	rec.Responses["list-panes"] = "0\t0\t1\t80\t24\t0\t0\tzsh\t/tmp\tdev\n0\t1\t0\t80\t24\t0\t0\tzsh\t/tmp\tdev\n"
+
+	plan, err := planSync(rec, session)
+	require.Nil(t, err)
+
+	require.Len(t, plan.windows, 1)
+	assert.Equal(t, "logs", plan.windows[0].window.Title)
+	assert.Equal(t, []string{
+		`window "dev" has 2 panes but the config has 4, nested splits can only be added with new windows`,
+	}, plan.notes)
+}
+
+// TestPlanSyncNoPanes checks that a running session with no listed panes is not planned as empty
+func TestPlanSyncNoPanes(t *testing.T) {
+	var (
+		rec     = tmux.NewRecorder("e2e")
+		session = config.Session{SessionId: "e2e", Directory: "/tmp", Windows: []config.Window{{Title: "dev"}}}
+	)
+	rec.Responses["list-panes"] = "0_0_1_80_24_0_0_zsh_/tmp_dev\n"
+
+	_, err := planSync(rec, session)
+	require.EqualError(t, err, "failed to list the panes of running session e2e")
+}
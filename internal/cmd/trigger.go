@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
-	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/indeedhat/automux/internal/config"
 	"github.com/indeedhat/automux/internal/tmux"
@@ -16,8 +16,13 @@ import (
 )
 
 var (
-	triggerFlagDebug    bool
-	triggerFlagDetached bool
+	triggerFlagDebug        bool
+	triggerFlagDetached     bool
+	triggerFlagAbortOnError bool
+	triggerFlagNoSwitch     bool
+	triggerFlagVars         []string
+	flagNoGlobal            bool
+	triggerFlagSocket       socketFlags
 )
 
 func Trigger() *cobra.Command {
@@ -26,6 +31,8 @@ func Trigger() *cobra.Command {
 		Short: "Trigger the automux config in the current directory, if present",
 		Args:  cobra.MaximumNArgs(1),
 		RunE:  triggerCmd,
+		// build failures are reported in full so there is no need to print the usage as well
+		SilenceUsage: true,
 	}
 
 	cmd.Flags().BoolVar(&triggerFlagDebug, "debug", false, "print tmux commands rather than running them")
@@ -36,13 +43,42 @@ func Trigger() *cobra.Command {
 		false,
 		"Run the automux session detached\nThis will allow you to start an automux session from another session",
 	)
+	cmd.Flags().BoolVar(
+		&triggerFlagAbortOnError,
+		"abort-on-error",
+		false,
+		"Kill a partially created session as soon as any tmux command fails\n"+
+			"By default automux will finish building the session and report all failures",
+	)
+	cmd.Flags().BoolVar(
+		&triggerFlagNoSwitch,
+		"no-switch",
+		false,
+		"Do nothing when run from inside tmux rather than switching the client to the session",
+	)
+	cmd.Flags().StringArrayVar(
+		&triggerFlagVars,
+		"var",
+		nil,
+		"Set a config variable as key=value, this overrides the value in the configs vars block\n"+
+			"Can be given multiple times",
+	)
+	triggerFlagSocket.register(cmd)
+
+	// this is shared by every command that loads a config
+	cmd.PersistentFlags().BoolVar(
+		&flagNoGlobal,
+		"no-global",
+		false,
+		"Do not load the defaults from the users global config",
+	)
 
 	return cmd
 }
 
 func triggerCmd(cmd *cobra.Command, args []string) error {
-	// if we are already in a tmux session then there is nothing to do
-	if os.Getenv("TMUX") != "" && !triggerFlagDetached {
+	// switching has been opted out of so there is nothing to do from inside tmux
+	if os.Getenv("TMUX") != "" && triggerFlagNoSwitch && !triggerFlagDetached {
 		return nil
 	}
 
@@ -55,12 +91,12 @@ func triggerCmd(cmd *cobra.Command, args []string) error {
 		configPath = args[0]
 	}
 
-	conf, err := config.LoadAny(
-		configPath,
-		cmd.Context().Value("logger").(*log.Logger),
-		triggerFlagDebug,
-		triggerFlagDetached,
-	)
+	vars, err := parseVars(triggerFlagVars)
+	if err != nil {
+		return err
+	}
+
+	conf, err := config.LoadAny(configPath, triggerFlagDetached, vars, !flagNoGlobal)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil
@@ -69,9 +105,40 @@ func triggerCmd(cmd *cobra.Command, args []string) error {
 		return errors.New("!! invalid automux config !!\n " + err.Error())
 	}
 
-	masterSession := conf.AsSession()
+	triggerFlagSocket.apply(conf)
+
+	return launch(cmd, tmuxClient(cmd, triggerFlagDebug), conf, triggerFlagAbortOnError)
+}
+
+// parseVars parses the key=value pairs given to the --var flag
+func parseVars(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+
+	vars := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q, expected key=value", pair)
+		}
+
+		vars[key] = value
+	}
+
+	return vars, nil
+}
 
-	if tmux.SessionExists(masterSession) {
+// launch creates the sessions described by the config if they are not already running then
+// moves the user into the master session
+func launch(cmd *cobra.Command, client tmux.Client, conf *config.Config, abortOnError bool) error {
+	var (
+		logger        = cmd.Context().Value("logger").(*log.Logger)
+		masterSession = conf.AsSession()
+		errs          []error
+	)
+
+	if client.SessionExists(masterSession) {
 		if conf.AttachExisting {
 			goto attach
 		}
@@ -79,135 +146,528 @@ func triggerCmd(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	createSession(masterSession)
+	if err := createSession(client, masterSession, abortOnError); err != nil {
+		if abortOnError {
+			return err
+		}
+
+		errs = append(errs, err)
+	}
 
-	for i, session := range conf.Sessions {
+	for i, session := range conf.BackgroundSessions() {
 		if session.SessionId == "" {
-			conf.L.Printf("Failed to start session %d: no session id set\n", i)
+			logger.Printf("Failed to start session %d: no session id set\n", i)
 			continue
 		}
-		if tmux.SessionExists(session) {
+		if client.SessionExists(session) {
 			continue
 		}
 
-		createSession(session)
+		if err := createSession(client, session, abortOnError); err != nil {
+			if abortOnError {
+				return err
+			}
+
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 
 attach:
-	if !conf.Debug && !conf.Detached {
-		cmd := exec.Command("tmux", "attach", "-t", conf.SessionId)
-		cmd.Stdout = os.Stdout
-		cmd.Stdin = os.Stdin
-		cmd.Stderr = os.Stderr
-		cmd.Run()
+	return enterSession(client, masterSession, conf.Detached)
+}
+
+// enterSession moves the user into the session unless running detached
+//
+// Nothing is done when the client is already in the session, e.g. when automux is run from a cd
+// hook while moving around within the project
+func enterSession(client tmux.Client, session config.Session, detached bool) error {
+	if detached {
+		return nil
+	}
+
+	inTmux := os.Getenv("TMUX") != ""
+	if inTmux {
+		// the client may be on another server so failing to read its session is not an error
+		if current, err := tmux.CurrentSession(client, session); err == nil && current == session.SessionId {
+			return nil
+		}
+	}
+
+	if session.Hooks != nil && len(session.Hooks.OnAttach) > 0 {
+		env, err := session.Environment()
+		if err != nil {
+			return err
+		}
+
+		session.Env = env
+		if err := runHooks(client, session, session.Hooks.OnAttach); err != nil {
+			return fmt.Errorf("%s: %w", hookElement(onAttachHook), err)
+		}
 	}
 
-	return nil
+	// we cant attach from within tmux without nesting sessions so move the current client instead
+	if inTmux {
+		return tmux.Cmd(client, session, "switch-client")
+	}
+
+	return client.Attach(session)
+}
+
+// tmuxClient resolves the tmux client that the command should use
+//
+// A client can be provided via the "tmux" context value, this is mostly useful for tests
+func tmuxClient(cmd *cobra.Command, debug bool) tmux.Client {
+	if client, ok := cmd.Context().Value("tmux").(tmux.Client); ok {
+		return client
+	}
+
+	if debug {
+		return tmux.NewDryRunClient(cmd.Context().Value("logger").(*log.Logger))
+	}
+
+	return tmux.NewExecClient()
 }
 
 // createSession creates a new tmux session, wait for the server to start it then
 // create the sessions layout based on the provided config
-func createSession(session config.Session) {
-	args := []string{"new-session", "-d", "-s", session.SessionId}
-	if session.Directory != "" {
-		args = append(args, "-c", session.Directory)
+//
+// Any tmux failures are returned as a *BuildError, if abortOnError is set then the build will stop
+// at the first failure and the partially created session will be killed
+func createSession(client tmux.Client, session config.Session, abortOnError bool) error {
+	b := &builder{
+		client:       client,
+		session:      session,
+		abortOnError: abortOnError,
+		err:          &BuildError{SessionId: session.SessionId},
 	}
 
-	cmd := exec.Command("tmux", args...)
+	// the env files are loaded up front so their variables are in place before any exec is run
+	env, err := session.Environment()
+	if err != nil {
+		b.fail(sessionElement, err)
+		return b.err
+	}
+
+	session.Env = env
+	b.session.Env = env
+
+	hooks := session.Hooks
+	if hooks == nil {
+		hooks = &config.Hooks{}
+	}
+
+	if !b.runHook(beforeCreateHook, hooks.BeforeCreate) {
+		return b.err
+	}
+
+	var args []string
+	// -f is a server flag so must come before the command
 	if session.ConfigPath != nil && *session.ConfigPath != "" {
-		cmd.Args = append(cmd.Args, "-f", *session.ConfigPath)
+		args = append(args, "-f", *session.ConfigPath)
 	}
 
+	args = append(args, "new-session", "-d", "-s", session.SessionId)
 	if session.Directory != "" {
-		cmd.Dir = session.Directory
+		args = append(args, "-c", session.Directory)
+	}
+
+	// the first window is opened along with the session so gets its environment and run command from here
+	var (
+		windowEnv    map[string]string
+		windowRun    *string
+		windowRemain *bool
+	)
+	if len(session.Windows) > 0 {
+		windowEnv = session.Windows[0].Env
+		windowRun = session.Windows[0].Run
+		windowRemain = session.Windows[0].RemainOnExit
+	}
+	args = append(args, envArgs(config.MergeEnv(session.Env, windowEnv))...)
+	args = b.processArgs(args, windowRun, windowRemain, "")
+
+	if err := client.Run(session, args...); err != nil {
+		b.err.Failures = append(b.err.Failures, BuildFailure{sessionElement, err})
+		return b.err
+	}
+
+	if err := client.AwaitSession(session); err != nil {
+		b.err.Failures = append(b.err.Failures, BuildFailure{sessionElement, err})
+		return b.err
+	}
+
+	b.restoreSessionEnv(windowEnv)
+	b.setKillHook()
+	b.processPanels()
+
+	if !abortOnError || !b.err.failed() {
+		b.runHook(afterCreateHook, hooks.AfterCreate)
+	}
+
+	if !b.err.failed() {
+		return nil
+	}
+
+	if abortOnError {
+		b.err.Aborted = true
+		if err := tmux.Cmd(client, session, "kill-session"); err != nil {
+			b.err.Failures = append(b.err.Failures, BuildFailure{sessionElement, err})
+		}
+	}
+
+	return b.err
+}
+
+// builder applies a sessions config to tmux, collecting any failures along the way
+type builder struct {
+	client       tmux.Client
+	session      config.Session
+	abortOnError bool
+	// background opens new panes without making them the active pane, this is used when adding
+	// to a session that is already running
+	background bool
+	// panes contains the target of each window/named split that has been opened by title/name
+	panes map[string]string
+	err   *BuildError
+}
+
+// cmd runs a tmux command against the session and records any failure against the config element
+//
+// Once a failure has been recorded in abort mode all further commands are skipped
+func (b *builder) cmd(element string, parts ...string) {
+	b.targetCmd(element, b.session.SessionId, parts...)
+}
+
+// targetCmd runs a tmux command against a specific window/pane rather than the session
+func (b *builder) targetCmd(element, target string, parts ...string) {
+	if b.abortOnError && b.err.failed() {
+		return
+	}
+
+	session := b.session
+	session.SessionId = target
+
+	if err := tmux.Cmd(b.client, session, parts...); err != nil {
+		b.fail(element, err)
+	}
+}
+
+// paneCmd runs a tmux command that opens a new pane from the target and returns the id of the
+// new pane, false is returned if the pane could not be opened
+func (b *builder) paneCmd(element, target string, parts ...string) (string, bool) {
+	if b.abortOnError && b.err.failed() {
+		return "", false
 	}
 
-	if session.Debug {
-		session.L.Println(strings.Join(cmd.Args, " "))
-	} else {
-		cmd.Run()
+	args := append([]string{parts[0], "-t", target, "-P", "-F", "#{pane_id}"}, parts[1:]...)
+
+	out, err := b.client.Output(b.session, args...)
+	if err != nil {
+		b.fail(element, err)
+		return "", false
 	}
 
-	tmux.AwaitSession(session)
-	processPanels(session)
+	return paneId(out, element), true
+}
+
+// fail records a failure against the config element
+func (b *builder) fail(element string, err error) {
+	b.err.Failures = append(b.err.Failures, BuildFailure{element, err})
+}
+
+// restoreSessionEnv puts back the session environment after the first windows environment has
+// been used to create the session
+//
+// new-session -e sets the environment for the whole session so any variables that only belong to
+// the first window are removed again to stop them leaking into the rest of the windows
+func (b *builder) restoreSessionEnv(windowEnv map[string]string) {
+	for _, key := range sortedKeys(windowEnv) {
+		value, ok := b.session.Env[key]
+		if !ok {
+			b.cmd(sessionElement, "set-environment", "-u", key)
+		} else if value != windowEnv[key] {
+			b.cmd(sessionElement, "set-environment", key, value)
+		}
+	}
 }
 
 // processPanels walkes through the configs windows/splits an applies them to the current tmux session
-func processPanels(session config.Session) {
+func (b *builder) processPanels() {
 	var focus string
 
-	for i, window := range session.Windows {
+	for i, window := range b.session.Windows {
+		element := windowElement(window.Title)
+
 		if window.Focus != nil && *window.Focus {
-			focus = fmt.Sprintf(":%d.%d", i, 0)
+			focus = fmt.Sprintf("%s:%d.%d", b.session.SessionId, i, 0)
 		}
 
 		if i != 0 {
+			args := []string{"new-window"}
 			if window.Directory != nil && *window.Directory != "" {
-				tmux.Cmd(session, "new-window", "-c", *window.Directory)
-			} else {
-				tmux.Cmd(session, "new-window")
+				args = append(args, "-c", *window.Directory)
 			}
+
+			args = append(args, envArgs(window.Env)...)
+			b.cmd(element, b.processArgs(args, window.Run, window.RemainOnExit, "last-window")...)
 		}
 
 		// renaming the window for some reasonstops issues with blank splits
-		tmux.Cmd(session, "rename-window", window.Title)
+		b.cmd(element, "rename-window", window.Title)
 
-		if window.Exec != nil && *window.Exec != "" {
-			tmux.Cmd(session, "send-keys", *window.Exec, "Enter")
+		b.registerPane(window.Title, fmt.Sprintf("%s:%d.%d", b.session.SessionId, i, 0))
+		if b.waitFor(element, window.WaitFor) {
+			b.sendExec(element, b.session.SessionId, window.Exec, window.ExecDelay)
 		}
 
-		processSplits(window, session, &focus, i)
+		if window.HasSplitTree() {
+			if root, ok := b.activePane(element); ok {
+				b.processSplitTree(window, root, &focus)
+			}
+		} else {
+			b.processSplits(window, &focus, i)
+		}
+		b.applyLayout(window, b.session.SessionId)
 
 		// stops the opening of programs from overwriting tab
-		tmux.Cmd(session, "rename-window", window.Title)
+		b.cmd(element, "rename-window", window.Title)
 	}
 
 	if focus != "" {
-		// replacing the session id is hacky and i hate it but im too lazy to come up witha proper
-		// solution for now
-		ses := session.SessionId
-		session.SessionId += focus
-		tmux.Cmd(session, "select-window")
-		tmux.Cmd(session, "select-pane")
-		session.SessionId = ses
+		b.targetCmd(sessionElement, focus, "select-window")
+		b.targetCmd(sessionElement, focus, "select-pane")
 	}
 }
 
-// processSplits loops over the windows splits and adds them to the session
-func processSplits(window config.Window, session config.Session, focus *string, i int) {
-	for j, split := range window.Splits {
-		if split.Focus != nil && *split.Focus {
-			*focus = fmt.Sprintf(":%d.%d", i, j+1)
+// activePane returns the id of the active pane in the session
+func (b *builder) activePane(element string) (string, bool) {
+	if b.abortOnError && b.err.failed() {
+		return "", false
+	}
+
+	out, err := b.client.Output(b.session, "display-message", "-p", "-t", b.session.SessionId, "#{pane_id}")
+	if err != nil {
+		b.fail(element, err)
+		return "", false
+	}
+
+	return paneId(out, element), true
+}
+
+// paneId reads the pane id from the output of a tmux command
+//
+// In debug mode there is no output so the pane is named after its config element instead to keep
+// the printed commands readable
+func paneId(out, element string) string {
+	if id := strings.TrimSpace(out); id != "" {
+		return id
+	}
+
+	return "{" + element + "}"
+}
+
+// applyLayout arranges the panes of the target window with its layout if it has one
+func (b *builder) applyLayout(window config.Window, target string) {
+	if window.Layout == nil || *window.Layout == "" {
+		return
+	}
+
+	element := windowElement(window.Title)
+	if err := window.ValidateLayout(); err != nil {
+		b.fail(element, err)
+		return
+	}
+
+	b.targetCmd(element, target, "select-layout", config.LayoutWithChecksum(*window.Layout))
+}
+
+// sendExec types each of the exec commands into the target pane in order
+//
+// If a delay is set it is waited before each command after the first to give the previous one
+// time to start up
+func (b *builder) sendExec(element, target string, exec config.StringList, delay *int) {
+	for i, command := range exec {
+		if command == "" {
+			continue
 		}
 
-		// This looks backwards but it makes the splits open in the way i expect
-		orientation := "-v"
-		resize := "-y"
-		if split.Vertical != nil && *split.Vertical {
-			orientation = "-h"
-			resize = "-x"
+		if i > 0 && delay != nil && *delay > 0 {
+			time.Sleep(time.Duration(*delay) * time.Millisecond)
 		}
 
-		if window.Directory != nil && *window.Directory != "" {
-			if split.Directory != nil {
-				*split.Directory = path.Join(*window.Directory, *split.Directory)
-			} else {
-				split.Directory = window.Directory
+		b.targetCmd(element, target, "send-keys", command, "Enter")
+	}
+}
+
+// processSplitTree opens the windows splits from the given root pane using explicit pane targets
+// so that splits can be nested and can target any pane opened before them
+func (b *builder) processSplitTree(window config.Window, root string, focus *string) {
+	state := &splitTreeState{
+		window: window,
+		panes:  map[string]string{window.Title: root},
+		focus:  focus,
+	}
+
+	b.splitTree(state, window.Splits, root, window.Env)
+}
+
+// splitTreeState is shared between every level of a split tree while it is being opened
+type splitTreeState struct {
+	window config.Window
+	// panes contains the id of each named split (and the window) that has been opened
+	panes map[string]string
+	// number is the number of splits that have been opened, used to name elements in errors
+	number int
+	focus  *string
+}
+
+// splitTree opens each of the splits followed by their nested splits
+//
+// A split without a target is opened from the pane of the split before it in the same block, the
+// first split in a block is opened from the blocks own pane. Splits are numbered in the order
+// they are opened for error reporting and inherit the environment of the block they are in
+func (b *builder) splitTree(state *splitTreeState, splits []config.Split, parent string, env map[string]string) {
+	previous := parent
+
+	for _, split := range splits {
+		state.number++
+		element := splitElement(state.window.Title, state.number)
+
+		target := previous
+		if split.Target != nil && *split.Target != "" {
+			var ok bool
+			if target, ok = state.panes[*split.Target]; !ok {
+				b.fail(element, fmt.Errorf("unknown split target %q", *split.Target))
+				state.number += split.NestedCount()
+				continue
 			}
 		}
 
-		splitArgs := []string{"split-window", orientation}
-		if split.Directory != nil && *split.Directory != "" {
-			splitArgs = append(splitArgs, "-c", *split.Directory)
+		splitArgs, resize := splitCommand(state.window, split, env)
+		splitArgs = b.processArgs(splitArgs, split.Run, split.RemainOnExit, "last-pane")
+
+		pane, ok := b.paneCmd(element, target, splitArgs...)
+		if !ok {
+			state.number += split.NestedCount()
+			continue
 		}
 
-		tmux.Cmd(session, splitArgs...)
+		if split.Name != nil && *split.Name != "" {
+			state.panes[*split.Name] = pane
+			b.registerPane(*split.Name, pane)
+		}
+		if split.Focus != nil && *split.Focus {
+			*state.focus = pane
+		}
 
 		if split.Size != nil && *split.Size != 0 {
-			tmux.Cmd(session, "resize-pane", resize, strconv.Itoa(*split.Size)+"%")
+			b.targetCmd(element, pane, "resize-pane", resize, strconv.Itoa(*split.Size)+"%")
 		}
-		if split.Exec != nil && *split.Exec != "" {
-			tmux.Cmd(session, "send-keys", *split.Exec, "Enter")
+		if b.waitFor(element, split.WaitFor) {
+			b.sendExec(element, pane, split.Exec, split.ExecDelay)
 		}
+
+		b.splitTree(state, split.Splits, pane, config.MergeEnv(env, split.Env))
+		previous = pane
 	}
 }
+
+// processSplits loops over the windows splits and adds them to the session
+func (b *builder) processSplits(window config.Window, focus *string, i int) {
+	for j, split := range window.Splits {
+		element := splitElement(window.Title, j+1)
+
+		if split.Focus != nil && *split.Focus {
+			*focus = fmt.Sprintf("%s:%d.%d", b.session.SessionId, i, j+1)
+		}
+
+		splitArgs, resize := splitCommand(window, split, window.Env)
+		b.cmd(element, b.processArgs(splitArgs, split.Run, split.RemainOnExit, "last-pane")...)
+
+		if split.Size != nil && *split.Size != 0 {
+			b.cmd(element, "resize-pane", resize, strconv.Itoa(*split.Size)+"%")
+		}
+		if split.Name != nil && *split.Name != "" {
+			b.registerPane(*split.Name, fmt.Sprintf("%s:%d.%d", b.session.SessionId, i, j+1))
+		}
+		if b.waitFor(element, split.WaitFor) {
+			b.sendExec(element, b.session.SessionId, split.Exec, split.ExecDelay)
+		}
+	}
+}
+
+// splitCommand builds the split-window args for a split along with the resize-pane flag that
+// matches its orientation
+//
+// env is the environment inherited from the window (or parent split), the splits own environment
+// is added on top of it
+func splitCommand(window config.Window, split config.Split, env map[string]string) ([]string, string) {
+	// This looks backwards but it makes the splits open in the way i expect
+	orientation := "-v"
+	resize := "-y"
+	if split.Vertical != nil && *split.Vertical {
+		orientation = "-h"
+		resize = "-x"
+	}
+
+	args := []string{"split-window", orientation}
+	if dir := window.SplitDirectory(split); dir != "" {
+		args = append(args, "-c", dir)
+	}
+
+	return append(args, envArgs(config.MergeEnv(env, split.Env))...), resize
+}
+
+// processArgs finishes the args of a command that opens a pane by adding the run command that
+// will be the panes process
+//
+// tmux stops looking for flags at the run command so it must be the last argument. remain-on-exit
+// is set by a second command in the same tmux invocation so that a run command that exits
+// straight away can not close the pane before the option is set. The option is set on the active
+// pane so background panes that need it are opened in the foreground and restore is run after to
+// go back to the previous pane/window
+func (b *builder) processArgs(args []string, run *string, remain *bool, restore string) []string {
+	keep := remain != nil && *remain
+
+	if b.background && !keep {
+		args = append(args, "-d")
+	}
+
+	if run != nil && *run != "" {
+		args = append(args, *run)
+	}
+
+	if keep {
+		args = append(args, ";", "set-option", "-p", "remain-on-exit", "on")
+		if b.background {
+			args = append(args, ";", restore)
+		}
+	}
+
+	return args
+}
+
+// envArgs converts the environment variables into -e flags in a stable order
+func envArgs(env map[string]string) []string {
+	var args []string
+	for _, key := range sortedKeys(env) {
+		args = append(args, "-e", key+"="+env[key])
+	}
+
+	return args
+}
+
+// sortedKeys returns the keys of the map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -3,46 +3,54 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-var triggerCmdDebugText = `tmux  rename-window -t automux-trigger-config Editor
-tmux  send-keys -t automux-trigger-config nvim Enter
-tmux  split-window -t automux-trigger-config -h
-tmux  resize-pane -t automux-trigger-config -x 20%
-tmux  send-keys -t automux-trigger-config htop Enter
-tmux  split-window -t automux-trigger-config -v -c sub/
-tmux  resize-pane -t automux-trigger-config -y 60%
-tmux  rename-window -t automux-trigger-config Editor
-tmux  select-window -t automux-trigger-config:0.1
-tmux  select-pane -t automux-trigger-config:0.1
-tmux new-session -d -s sub-automux-trigger-config-sub -c ../../_examples/
-tmux  rename-window -t sub-automux-trigger-config-sub Editor
-tmux  send-keys -t sub-automux-trigger-config-sub nvim Enter
-tmux  split-window -t sub-automux-trigger-config-sub -h
-tmux  resize-pane -t sub-automux-trigger-config-sub -x 20%
-tmux  send-keys -t sub-automux-trigger-config-sub htop Enter
-tmux  split-window -t sub-automux-trigger-config-sub -v -c sub/
-tmux  resize-pane -t sub-automux-trigger-config-sub -y 60%
-tmux  rename-window -t sub-automux-trigger-config-sub Editor
-tmux  new-window -t sub-automux-trigger-config-sub -c window_sub/
-tmux  rename-window -t sub-automux-trigger-config-sub Editor
-tmux  send-keys -t sub-automux-trigger-config-sub nvim Enter
-tmux  split-window -t sub-automux-trigger-config-sub -h -c window_sub/
-tmux  resize-pane -t sub-automux-trigger-config-sub -x 20%
-tmux  send-keys -t sub-automux-trigger-config-sub htop Enter
-tmux  split-window -t sub-automux-trigger-config-sub -v -c window_sub/sub
-tmux  resize-pane -t sub-automux-trigger-config-sub -y 60%
-tmux  rename-window -t sub-automux-trigger-config-sub Editor
-tmux  select-window -t sub-automux-trigger-config-sub:1.1
-tmux  select-pane -t sub-automux-trigger-config-sub:1.1
-`
+var triggerCmdExpectedArgs = [][]string{
+	{"rename-window", "-t", "automux-trigger-config", "Editor"},
+	{"send-keys", "-t", "automux-trigger-config", "nvim", "Enter"},
+	{"split-window", "-t", "automux-trigger-config", "-h"},
+	{"resize-pane", "-t", "automux-trigger-config", "-x", "20%"},
+	{"send-keys", "-t", "automux-trigger-config", "htop", "Enter"},
+	{"split-window", "-t", "automux-trigger-config", "-v", "-c", "sub/"},
+	{"resize-pane", "-t", "automux-trigger-config", "-y", "60%"},
+	{"rename-window", "-t", "automux-trigger-config", "Editor"},
+	{"select-window", "-t", "automux-trigger-config:0.1"},
+	{"select-pane", "-t", "automux-trigger-config:0.1"},
+	{"new-session", "-d", "-s", "sub-automux-trigger-config-sub", "-c", "../../_examples/"},
+	{"rename-window", "-t", "sub-automux-trigger-config-sub", "Editor"},
+	{"send-keys", "-t", "sub-automux-trigger-config-sub", "nvim", "Enter"},
+	{"split-window", "-t", "sub-automux-trigger-config-sub", "-h"},
+	{"resize-pane", "-t", "sub-automux-trigger-config-sub", "-x", "20%"},
+	{"send-keys", "-t", "sub-automux-trigger-config-sub", "htop", "Enter"},
+	{"split-window", "-t", "sub-automux-trigger-config-sub", "-v", "-c", "sub/"},
+	{"resize-pane", "-t", "sub-automux-trigger-config-sub", "-y", "60%"},
+	{"rename-window", "-t", "sub-automux-trigger-config-sub", "Editor"},
+	{"new-window", "-t", "sub-automux-trigger-config-sub", "-c", "window_sub/"},
+	{"rename-window", "-t", "sub-automux-trigger-config-sub", "Editor"},
+	{"send-keys", "-t", "sub-automux-trigger-config-sub", "nvim", "Enter"},
+	{"split-window", "-t", "sub-automux-trigger-config-sub", "-h", "-c", "window_sub/"},
+	{"resize-pane", "-t", "sub-automux-trigger-config-sub", "-x", "20%"},
+	{"send-keys", "-t", "sub-automux-trigger-config-sub", "htop", "Enter"},
+	{"split-window", "-t", "sub-automux-trigger-config-sub", "-v", "-c", "window_sub/sub"},
+	{"resize-pane", "-t", "sub-automux-trigger-config-sub", "-y", "60%"},
+	{"rename-window", "-t", "sub-automux-trigger-config-sub", "Editor"},
+	{"select-window", "-t", "sub-automux-trigger-config-sub:1.1"},
+	{"select-pane", "-t", "sub-automux-trigger-config-sub:1.1"},
+}
 
 func TestTriggerCmdTmuxSet(t *testing.T) {
 	orig := os.Getenv("TMUX")
@@ -62,6 +70,92 @@ func TestTriggerCmdTmuxSet(t *testing.T) {
 	assert.Nil(t, c.ExecuteContext(ctx), "TriggerCmd")
 }
 
+// TestTriggerCmdSwitchClient checks that when run from inside tmux the client is switched to the
+// session rather than attached
+func TestTriggerCmdSwitchClient(t *testing.T) {
+	orig := os.Getenv("TMUX")
+	os.Setenv("TMUX", "1")
+	defer func() {
+		os.Setenv("TMUX", orig)
+	}()
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(`
+version = 1
+session_id = "automux-trigger-switch"
+window "Editor" {}
+`)
+
+	switchArgs := []string{"switch-client", "-t", "automux-trigger-switch"}
+
+	checks := []struct {
+		name     string
+		args     []string
+		existing []string
+		last     []string
+		count    int
+	}{
+		{"new-session", []string{tmpPath.Name()}, nil, switchArgs, 5},
+		{"existing-session", []string{tmpPath.Name()}, []string{"automux-trigger-switch"}, switchArgs, 2},
+		{"detached", []string{"-d", tmpPath.Name()}, nil, []string{"rename-window", "-t", "automux-trigger-switch", "Editor"}, 3},
+		{"no-switch", []string{"--no-switch", tmpPath.Name()}, nil, nil, 0},
+	}
+
+	for _, check := range checks {
+		t.Run(check.name, func(t *testing.T) {
+			var (
+				b   bytes.Buffer
+				l   = log.New(&b, "", 0)
+				rec = tmux.NewRecorder(check.existing...)
+			)
+
+			ctx := context.WithValue(context.Background(), "logger", l)
+			ctx = context.WithValue(ctx, "tmux", rec)
+
+			c := Trigger()
+			c.SetArgs(check.args)
+
+			require.Nil(t, c.ExecuteContext(ctx), "TriggerCmd")
+
+			args := rec.Args()
+			require.Len(t, args, check.count)
+			if check.last != nil {
+				assert.Equal(t, check.last, args[len(args)-1])
+			}
+		})
+	}
+}
+
+// TestTriggerCmdAlreadyInSession checks that nothing is run when the client is already in the
+// session, e.g. when automux is run from a cd hook within the project
+func TestTriggerCmdAlreadyInSession(t *testing.T) {
+	t.Setenv("TMUX", "1")
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".automux")
+	require.Nil(t, os.WriteFile(configPath, []byte(triggerHooksDocument), 0644))
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder("automux-trigger-hooks")
+	)
+	rec.Responses["display-message"] = "automux-trigger-hooks\n"
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{configPath})
+
+	require.Nil(t, c.ExecuteContext(ctx))
+	assert.Empty(t, rec.Shells)
+	assert.Equal(t, [][]string{{"display-message", "-p", "#{session_name}"}}, rec.Args())
+}
+
 var triggerIclDocument = `
 version = 1
 session_id = "automux-trigger-config"
@@ -124,19 +218,7 @@ func TestTriggerCmdMultiSession(t *testing.T) {
 
 	tmpPath.WriteString(triggerIclDocument)
 
-	var b bytes.Buffer
-	var l = log.New(&b, "", 0)
-
-	ctx := context.WithValue(context.Background(), "logger", l)
-
-	c := Trigger()
-	c.SetArgs([]string{"--debug", "--detached", tmpPath.Name()})
-
-	assert.Nil(t, c.ExecuteContext(ctx), "TriggerCmd")
-	parts := strings.SplitN(b.String(), "\n", 2)
-
-	assert.True(t, strings.HasPrefix(parts[0], "tmux new-session -d -s automux-trigger-config -c /tmp/"))
-	assert.Equal(t, triggerCmdDebugText, parts[1], "Debug info")
+	t_assertTriggerCommands(t, tmpPath.Name())
 }
 
 var triggerJsonDocument = `
@@ -217,19 +299,7 @@ func TestTriggerCmdMultiSessionWithJson(t *testing.T) {
 
 	tmpPath.WriteString(triggerJsonDocument)
 
-	var b bytes.Buffer
-	var l = log.New(&b, "", 0)
-
-	ctx := context.WithValue(context.Background(), "logger", l)
-
-	c := Trigger()
-	c.SetArgs([]string{"--debug", "--detached", tmpPath.Name()})
-
-	assert.Nil(t, c.ExecuteContext(ctx), "TriggerCmd")
-	parts := strings.SplitN(b.String(), "\n", 2)
-
-	assert.True(t, strings.HasPrefix(parts[0], "tmux new-session -d -s automux-trigger-config -c /tmp/"))
-	assert.Equal(t, triggerCmdDebugText, parts[1], "Debug info")
+	t_assertTriggerCommands(t, tmpPath.Name())
 }
 
 var triggerYamlDocument = `
@@ -282,6 +352,19 @@ func TestTriggerCmdMultiSessionWithYaml(t *testing.T) {
 
 	tmpPath.WriteString(triggerYamlDocument)
 
+	t_assertTriggerCommands(t, tmpPath.Name())
+}
+
+// TestTriggerCmdDebug checks that the --debug flag prints the tmux commands rather than running them
+func TestTriggerCmdDebug(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerIclDocument)
+
 	var b bytes.Buffer
 	var l = log.New(&b, "", 0)
 
@@ -291,8 +374,894 @@ func TestTriggerCmdMultiSessionWithYaml(t *testing.T) {
 	c.SetArgs([]string{"--debug", "--detached", tmpPath.Name()})
 
 	assert.Nil(t, c.ExecuteContext(ctx), "TriggerCmd")
-	parts := strings.SplitN(b.String(), "\n", 2)
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+
+	require.Len(t, lines, len(triggerCmdExpectedArgs)+1)
+	assert.Equal(t, "tmux new-session -d -s automux-trigger-config -c "+filepath.Dir(tmpPath.Name()), lines[0])
+	assert.Equal(t, "tmux rename-window -t automux-trigger-config Editor", lines[1])
+}
+
+// TestTriggerCmdAttachExisting checks that nothing is built when the session is already running
+func TestTriggerCmdAttachExisting(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerIclDocument)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder("automux-trigger-config")
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{tmpPath.Name()})
+
+	assert.Nil(t, c.ExecuteContext(ctx), "TriggerCmd")
+	assert.Equal(t, [][]string{{"attach", "-t", "automux-trigger-config"}}, rec.Args())
+}
+
+// t_assertTriggerCommands runs the trigger command against the given config with a recording
+// client and checks the commands that would have been sent to tmux
+func t_assertTriggerCommands(t *testing.T, configPath string) {
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", configPath})
+
+	assert.Nil(t, c.ExecuteContext(ctx), "TriggerCmd")
+
+	args := rec.Args()
+	require.NotEmpty(t, args)
+
+	assert.Equal(t, []string{"new-session", "-d", "-s", "automux-trigger-config", "-c", filepath.Dir(configPath)}, args[0])
+	assert.Equal(t, triggerCmdExpectedArgs, args[1:], "tmux commands")
+}
+
+// TestTriggerCmdBuildError checks that tmux failures are collected and reported against the
+// config element that caused them while the rest of the session continues to build
+func TestTriggerCmdBuildError(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerIclDocument)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+	rec.FailOn = t_failOnSplitDir("sub/")
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", tmpPath.Name()})
 
-	assert.True(t, strings.HasPrefix(parts[0], "tmux new-session -d -s automux-trigger-config -c /tmp/"))
-	assert.Equal(t, triggerCmdDebugText, parts[1], "Debug info")
+	err = c.ExecuteContext(ctx)
+	require.NotNil(t, err)
+
+	var buildErr *BuildError
+	require.ErrorAs(t, err, &buildErr)
+	assert.Equal(t, "automux-trigger-config", buildErr.SessionId)
+	assert.False(t, buildErr.Aborted)
+	require.Len(t, buildErr.Failures, 1)
+	assert.Equal(t, `window "Editor" split 2`, buildErr.Failures[0].Element)
+
+	// the rest of the master session and the sub session should still have been built
+	assert.Len(t, rec.Commands, len(triggerCmdExpectedArgs)+1)
+}
+
+// TestTriggerCmdAbortOnError checks that the build stops on the first failure and the
+// partially created session is killed
+func TestTriggerCmdAbortOnError(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerIclDocument)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+	rec.FailOn = t_failOnSplitDir("sub/")
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", "--abort-on-error", tmpPath.Name()})
+
+	err = c.ExecuteContext(ctx)
+	require.NotNil(t, err)
+
+	var buildErr *BuildError
+	require.ErrorAs(t, err, &buildErr)
+	assert.True(t, buildErr.Aborted)
+	require.Len(t, buildErr.Failures, 1)
+	assert.Equal(t, `window "Editor" split 2`, buildErr.Failures[0].Element)
+
+	args := rec.Args()
+	assert.Equal(t, []string{"split-window", "-t", "automux-trigger-config", "-v", "-c", "sub/"}, args[len(args)-2])
+	assert.Equal(t, []string{"kill-session", "-t", "automux-trigger-config"}, args[len(args)-1])
+}
+
+// t_failOnSplitDir makes the recorder fail any split-window command opening the given directory
+func t_failOnSplitDir(dir string) func(tmux.Command) error {
+	return func(c tmux.Command) error {
+		if c.Args[0] == "split-window" && c.Args[len(c.Args)-1] == dir {
+			return errors.New("exit status 1")
+		}
+
+		return nil
+	}
+}
+
+var triggerSocketDocument = `
+version = 1
+session_id = "automux-trigger-socket"
+socket_name = "work"
+window "Editor" {}
+
+session "../../_examples/" {
+	session_id = "automux-trigger-socket-inherit"
+	window "Editor" {}
+}
+
+session "../../_examples/" {
+	session_id = "automux-trigger-socket-own"
+	socket_path = "/tmp/automux-personal"
+	window "Editor" {}
+}
+`
+
+// TestTriggerCmdSocket checks that every tmux command targets the configured server and that
+// the cli flag overrides the master server along with any sessions inheriting it
+func TestTriggerCmdSocket(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerSocketDocument)
+
+	checks := []struct {
+		name     string
+		args     []string
+		master   []string
+		inherits []string
+	}{
+		{"config", []string{tmpPath.Name()}, []string{"-L", "work"}, []string{"-L", "work"}},
+		{"flag", []string{"-L", "other", tmpPath.Name()}, []string{"-L", "other"}, []string{"-L", "other"}},
+		{"flag-path", []string{"-S", "/tmp/sock", tmpPath.Name()}, []string{"-S", "/tmp/sock"}, []string{"-S", "/tmp/sock"}},
+	}
+
+	for _, check := range checks {
+		t.Run(check.name, func(t *testing.T) {
+			var (
+				b   bytes.Buffer
+				l   = log.New(&b, "", 0)
+				rec = tmux.NewRecorder()
+			)
+
+			ctx := context.WithValue(context.Background(), "logger", l)
+			ctx = context.WithValue(ctx, "tmux", rec)
+
+			c := Trigger()
+			c.SetArgs(check.args)
+
+			require.Nil(t, c.ExecuteContext(ctx), "TriggerCmd")
+
+			servers := make(map[string][]string)
+			for _, args := range rec.Args() {
+				if args[2] == "new-session" {
+					servers[args[5]] = args[:2]
+				}
+			}
+
+			assert.Equal(t, check.master, servers["automux-trigger-socket"])
+			assert.Equal(t, check.inherits, servers["automux-trigger-socket-inherit"])
+			assert.Equal(t, []string{"-S", "/tmp/automux-personal"}, servers["automux-trigger-socket-own"])
+
+			last := rec.Args()[len(rec.Args())-1]
+			assert.Equal(t, append(check.master, "attach", "-t", "automux-trigger-socket"), last)
+		})
+	}
+}
+
+var triggerLayoutDocument = `
+version = 1
+session_id = "automux-trigger-layout"
+
+window "Editor" {
+    layout = "main-vertical"
+    split {}
+    split {}
+}
+
+window "Raw" {
+    layout = "80x24,0,0{40x24,0,0,0,39x24,41,0,1}"
+    split {}
+}
+
+window "Broken" {
+    layout = "80x24,0,0{40x24,0,0,0,39x24,41,0,1}"
+}
+`
+
+// TestTriggerCmdLayout checks that layouts are applied once the splits exist and that raw layouts
+// with the wrong number of panes are reported rather than sent to tmux
+func TestTriggerCmdLayout(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerLayoutDocument)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", tmpPath.Name()})
+
+	err = c.ExecuteContext(ctx)
+
+	var buildErr *BuildError
+	require.ErrorAs(t, err, &buildErr)
+	require.Len(t, buildErr.Failures, 1)
+	assert.Equal(t, `window "Broken"`, buildErr.Failures[0].Element)
+
+	var layouts [][]string
+	for i, args := range rec.Args() {
+		if args[0] != "select-layout" {
+			continue
+		}
+
+		// the layout must come after the last split of the window
+		assert.Equal(t, "split-window", rec.Args()[i-1][0])
+		layouts = append(layouts, args)
+	}
+
+	assert.Equal(t, [][]string{
+		{"select-layout", "-t", "automux-trigger-layout", "main-vertical"},
+		{"select-layout", "-t", "automux-trigger-layout", "8205,80x24,0,0{40x24,0,0,0,39x24,41,0,1}"},
+	}, layouts)
+}
+
+var triggerSplitTreeDocument = `
+version = 1
+session_id = "automux-trigger-tree"
+
+window "Dev" {
+    split {
+        name = "right"
+        vertical = true
+
+        split {}
+        split {
+            exec = "htop"
+            focus = true
+        }
+    }
+
+    split {
+        target = "Dev"
+        size = 30
+    }
+
+    split {
+        target = "missing"
+    }
+}
+`
+
+// TestTriggerCmdSplitTree checks that nested and targeted splits are opened from the correct panes
+func TestTriggerCmdSplitTree(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerSplitTreeDocument)
+
+	var (
+		b     bytes.Buffer
+		l     = log.New(&b, "", 0)
+		rec   = tmux.NewRecorder()
+		panes int
+	)
+
+	// every new pane gets the next pane id
+	rec.Respond = func(c tmux.Command) string {
+		id := fmt.Sprintf("%%%d", panes)
+		panes++
+
+		return id
+	}
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", tmpPath.Name()})
+
+	err = c.ExecuteContext(ctx)
+
+	var buildErr *BuildError
+	require.ErrorAs(t, err, &buildErr)
+	require.Len(t, buildErr.Failures, 1)
+	assert.Equal(t, `window "Dev" split 5`, buildErr.Failures[0].Element)
+
+	var filtered [][]string
+	for _, args := range rec.Args()[1:] {
+		if args[0] != "rename-window" {
+			filtered = append(filtered, args)
+		}
+	}
+
+	assert.Equal(t, [][]string{
+		{"display-message", "-p", "-t", "automux-trigger-tree", "#{pane_id}"},
+		{"split-window", "-t", "%0", "-P", "-F", "#{pane_id}", "-h"},
+		{"split-window", "-t", "%1", "-P", "-F", "#{pane_id}", "-v"},
+		{"split-window", "-t", "%2", "-P", "-F", "#{pane_id}", "-v"},
+		{"send-keys", "-t", "%3", "htop", "Enter"},
+		{"split-window", "-t", "%0", "-P", "-F", "#{pane_id}", "-v"},
+		{"resize-pane", "-t", "%4", "-y", "30%"},
+		{"select-window", "-t", "%3"},
+		{"select-pane", "-t", "%3"},
+	}, filtered)
+}
+
+var triggerEnvDocument = `
+version = 1
+session_id = "automux-trigger-env"
+env = {"APP_ENV": "dev", "PORT": "8080"}
+
+window "Server" {
+    env = {"PORT": "9090", "DEBUG": "1"}
+
+    split {
+        env = {"DEBUG": "0"}
+    }
+}
+
+window "Shell" {
+    env = {"EDITOR": "nvim"}
+
+    split {}
+}
+`
+
+// TestTriggerCmdEnv checks that environment variables are inherited from the session by windows
+// and from windows by splits
+func TestTriggerCmdEnv(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerEnvDocument)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", tmpPath.Name()})
+
+	require.Nil(t, c.ExecuteContext(ctx))
+
+	var filtered [][]string
+	for _, args := range rec.Args() {
+		switch args[0] {
+		case "new-session", "set-environment", "new-window", "split-window":
+			filtered = append(filtered, args)
+		}
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(tmpPath.Name()))
+	require.Nil(t, err)
+
+	assert.Equal(t, [][]string{
+		{
+			"new-session", "-d", "-s", "automux-trigger-env", "-c", dir,
+			"-e", "APP_ENV=dev", "-e", "DEBUG=1", "-e", "PORT=9090",
+		},
+		{"set-environment", "-t", "automux-trigger-env", "-u", "DEBUG"},
+		{"set-environment", "-t", "automux-trigger-env", "PORT", "8080"},
+		{"split-window", "-t", "automux-trigger-env", "-v", "-e", "DEBUG=0", "-e", "PORT=9090"},
+		{"new-window", "-t", "automux-trigger-env", "-e", "EDITOR=nvim"},
+		{"split-window", "-t", "automux-trigger-env", "-v", "-e", "EDITOR=nvim"},
+	}, filtered)
+}
+
+var triggerEnvFileDocument = `
+version = 1
+session_id = "automux-trigger-env-file"
+env_file = [".env", "-.env.missing"]
+env = {"PORT": "8080"}
+
+window "Server" {}
+`
+
+// TestTriggerCmdEnvFile checks that env files are loaded into the new session and that a missing
+// env file stops the session from being created
+func TestTriggerCmdEnvFile(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".automux")
+	require.Nil(t, os.WriteFile(configPath, []byte(triggerEnvFileDocument), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("APP_ENV=dev\nPORT=80\n"), 0644))
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", configPath})
+
+	require.Nil(t, c.ExecuteContext(ctx))
+	assert.Equal(t, []string{
+		"new-session", "-d", "-s", "automux-trigger-env-file", "-c", dir,
+		"-e", "APP_ENV=dev", "-e", "PORT=8080",
+	}, rec.Args()[0])
+
+	require.Nil(t, os.Remove(filepath.Join(dir, ".env")))
+	rec = tmux.NewRecorder()
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c = Trigger()
+	c.SetArgs([]string{"--detached", configPath})
+
+	var buildErr *BuildError
+	require.ErrorAs(t, c.ExecuteContext(ctx), &buildErr)
+	require.Len(t, buildErr.Failures, 1)
+	assert.Equal(t, "session", buildErr.Failures[0].Element)
+	assert.Empty(t, rec.Args())
+}
+
+var triggerHooksDocument = `
+version = 1
+session_id = "automux-trigger-hooks"
+
+hooks {
+    before_create = ["docker compose up -d", "make deps"]
+    after_create = "notify-send ready"
+    on_attach = "git fetch"
+    on_kill = "docker compose stop # tidy up"
+}
+
+window "Server" {}
+`
+
+// TestTriggerCmdHooks checks that each lifecycle hook is run at the right point
+func TestTriggerCmdHooks(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".automux")
+	require.Nil(t, os.WriteFile(configPath, []byte(triggerHooksDocument), 0644))
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{configPath})
+
+	require.Nil(t, c.ExecuteContext(ctx))
+	assert.Equal(t, []string{"docker compose up -d", "make deps", "notify-send ready", "git fetch"}, rec.Shells)
+
+	args := rec.Args()
+	assert.Equal(t, "new-session", args[0][0])
+	index := killHookStart("automux-trigger-hooks")
+	assert.Equal(t, [][]string{
+		{"show-options", "-gqv", fmt.Sprintf("@automux-on-kill-%d", index)},
+		{"set-option", "-g", fmt.Sprintf("@automux-on-kill-%d", index), "automux-trigger-hooks"},
+		{
+			"set-hook",
+			"-g",
+			fmt.Sprintf("session-closed[%d]", index),
+			"if-shell -F '#{==:#{hook_session_name},automux-trigger-hooks}' { run-shell 'cd '\\''" + dir +
+				"'\\'' && docker compose stop ## tidy up' ; " +
+				fmt.Sprintf("set-hook -gu 'session-closed[%d]' ; set-option -gu @automux-on-kill-%d }", index, index),
+		},
+	}, args[1:4])
+	assert.Equal(t, []string{"attach", "-t", "automux-trigger-hooks"}, args[len(args)-1])
+}
+
+// TestTriggerCmdBeforeCreateFailure checks that a failing before_create hook stops the session
+// from being created
+func TestTriggerCmdBeforeCreateFailure(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".automux")
+	require.Nil(t, os.WriteFile(configPath, []byte(triggerHooksDocument), 0644))
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	rec.FailOn = func(c tmux.Command) error {
+		if c.Args[len(c.Args)-1] == "docker compose up -d" {
+			return errors.New("exit status 1")
+		}
+
+		return nil
+	}
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", configPath})
+
+	var buildErr *BuildError
+	require.ErrorAs(t, c.ExecuteContext(ctx), &buildErr)
+	require.Len(t, buildErr.Failures, 1)
+	assert.Equal(t, `hook "before_create"`, buildErr.Failures[0].Element)
+	assert.Equal(t, []string{"docker compose up -d"}, rec.Shells)
+	assert.Empty(t, rec.Args())
+}
+
+var triggerExecStepsDocument = `
+version = 1
+session_id = "automux-trigger-exec"
+
+window "Server" {
+    exec = ["cd api", "source venv/bin/activate", "make run"]
+    exec_delay = 20
+
+    split {
+        exec = "htop"
+    }
+}
+`
+
+// TestTriggerCmdExecSteps checks that each exec command is sent in order with the delay between them
+func TestTriggerCmdExecSteps(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerExecStepsDocument)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", tmpPath.Name()})
+
+	start := time.Now()
+	require.Nil(t, c.ExecuteContext(ctx))
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	var keys [][]string
+	for _, args := range rec.Args() {
+		if args[0] == "send-keys" {
+			keys = append(keys, args)
+		}
+	}
+
+	assert.Equal(t, [][]string{
+		{"send-keys", "-t", "automux-trigger-exec", "cd api", "Enter"},
+		{"send-keys", "-t", "automux-trigger-exec", "source venv/bin/activate", "Enter"},
+		{"send-keys", "-t", "automux-trigger-exec", "make run", "Enter"},
+		{"send-keys", "-t", "automux-trigger-exec", "htop", "Enter"},
+	}, keys)
+}
+
+var triggerWaitForDocument = `
+version = 1
+session_id = "automux-trigger-wait"
+
+window "Services" {
+    exec = "docker compose up db"
+
+    split {
+        name = "db"
+        exec = "docker compose logs -f db"
+    }
+
+    split {
+        exec = "make run"
+
+        wait_for {
+            port = "%s"
+            file = "ready"
+            command = "true"
+            output = "ready to accept connections"
+            pane = "db"
+        }
+    }
+
+    split {
+        exec = "make worker"
+
+        wait_for {
+            file = "missing"
+            timeout = 0
+        }
+    }
+}
+`
+
+// TestTriggerCmdWaitFor checks that exec commands are held back until their wait_for conditions
+// are met and are not sent at all if they time out
+func TestTriggerCmdWaitFor(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, ".automux")
+	document := fmt.Sprintf(triggerWaitForDocument, listener.Addr().String())
+	require.Nil(t, os.WriteFile(configPath, []byte(document), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "ready"), nil, 0644))
+
+	var (
+		b        bytes.Buffer
+		l        = log.New(&b, "", 0)
+		rec      = tmux.NewRecorder()
+		captures int
+	)
+
+	// the database only becomes ready on the second check
+	rec.Respond = func(c tmux.Command) string {
+		if c.Args[0] != "capture-pane" {
+			return ""
+		}
+
+		captures++
+		if captures < 2 {
+			return "starting up"
+		}
+
+		return "LOG: database system is ready to accept connections"
+	}
+
+	waitInterval = time.Millisecond
+	defer func() { waitInterval = 250 * time.Millisecond }()
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", configPath})
+
+	var buildErr *BuildError
+	require.ErrorAs(t, c.ExecuteContext(ctx), &buildErr)
+	require.Len(t, buildErr.Failures, 1)
+	assert.Equal(t, `window "Services" split 3`, buildErr.Failures[0].Element)
+	assert.Equal(t, 2, captures)
+
+	var filtered [][]string
+	for _, args := range rec.Args() {
+		if args[0] == "send-keys" || args[0] == "capture-pane" {
+			filtered = append(filtered, args)
+		}
+	}
+
+	assert.Equal(t, [][]string{
+		{"send-keys", "-t", "automux-trigger-wait", "docker compose up db", "Enter"},
+		{"send-keys", "-t", "automux-trigger-wait", "docker compose logs -f db", "Enter"},
+		{"capture-pane", "-p", "-J", "-S", "-", "-t", "automux-trigger-wait:0.1"},
+		{"capture-pane", "-p", "-J", "-S", "-", "-t", "automux-trigger-wait:0.1"},
+		{"send-keys", "-t", "automux-trigger-wait", "make run", "Enter"},
+	}, filtered)
+}
+
+var triggerRunDocument = `
+version = 1
+session_id = "automux-trigger-run"
+
+window "Server" {
+    run = "make run"
+    remain_on_exit = true
+
+    split {
+        run = "htop"
+    }
+}
+
+window "Repl" {
+    run = "python"
+    exec = "import os"
+}
+`
+
+// TestTriggerCmdRun checks that run commands are passed to tmux as the panes process rather than
+// being typed into a shell
+func TestTriggerCmdRun(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerRunDocument)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", tmpPath.Name()})
+
+	require.Nil(t, c.ExecuteContext(ctx))
+
+	var filtered [][]string
+	for _, args := range rec.Args() {
+		switch args[0] {
+		case "new-session", "new-window", "split-window", "send-keys":
+			filtered = append(filtered, args)
+		}
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(tmpPath.Name()))
+	require.Nil(t, err)
+
+	assert.Equal(t, [][]string{
+		{
+			"new-session", "-d", "-s", "automux-trigger-run", "-c", dir,
+			"make run", ";", "set-option", "-p", "remain-on-exit", "on",
+		},
+		{"split-window", "-t", "automux-trigger-run", "-v", "htop"},
+		{"new-window", "-t", "automux-trigger-run", "python"},
+		{"send-keys", "-t", "automux-trigger-run", "import os", "Enter"},
+	}, filtered)
+}
+
+var triggerVarsDocument = `
+version = 1
+session_id = "automux-${name}"
+vars = {name: "trigger-vars", cmd: "make run"}
+
+window "Server" {
+    exec = "${cmd}"
+}
+`
+
+// TestTriggerCmdVars checks that --var overrides the variables defined in the config
+func TestTriggerCmdVars(t *testing.T) {
+	os.Unsetenv("TMUX")
+
+	tmpPath, err := os.CreateTemp("", "*.automux")
+	require.Nil(t, err)
+	defer os.Remove(tmpPath.Name())
+
+	tmpPath.WriteString(triggerVarsDocument)
+
+	var (
+		b   bytes.Buffer
+		l   = log.New(&b, "", 0)
+		rec = tmux.NewRecorder()
+	)
+
+	ctx := context.WithValue(context.Background(), "logger", l)
+	ctx = context.WithValue(ctx, "tmux", rec)
+
+	c := Trigger()
+	c.SetArgs([]string{"--detached", "--var", "cmd=make test", tmpPath.Name()})
+
+	require.Nil(t, c.ExecuteContext(ctx))
+
+	var sent [][]string
+	for _, args := range rec.Args() {
+		if args[0] == "send-keys" {
+			sent = append(sent, args)
+		}
+	}
+
+	assert.Equal(t, [][]string{
+		{"send-keys", "-t", "automux-trigger-vars", "make test", "Enter"},
+	}, sent)
+
+	c = Trigger()
+	c.SetArgs([]string{"--detached", "--var", "cmd", tmpPath.Name()})
+	assert.ErrorContains(t, c.ExecuteContext(ctx), `invalid --var "cmd"`)
+}
+
+var killHookIndexChecks = []struct {
+	name     string
+	owners   map[uint32]string
+	running  []string
+	expected uint32
+}{
+	{"free", nil, nil, 0},
+	{"own", map[uint32]string{0: "automux-kill-hook"}, []string{"automux-kill-hook"}, 0},
+	{"taken", map[uint32]string{0: "other", 1: "another"}, []string{"other", "another"}, 2},
+	{"stale", map[uint32]string{0: "other"}, nil, 0},
+}
+
+// TestKillHookIndex checks that the kill hook of another running session is never replaced
+func TestKillHookIndex(t *testing.T) {
+	start := killHookStart("automux-kill-hook")
+
+	for _, check := range killHookIndexChecks {
+		t.Run(check.name, func(t *testing.T) {
+			rec := tmux.NewRecorder(check.running...)
+			rec.Respond = func(c tmux.Command) string {
+				for offset, owner := range check.owners {
+					if c.Args[len(c.Args)-1] == fmt.Sprintf("@automux-on-kill-%d", start+offset) {
+						return owner + "\n"
+					}
+				}
+
+				return ""
+			}
+
+			b := &builder{client: rec, session: config.Session{SessionId: "automux-kill-hook"}}
+
+			index, err := b.killHookIndex()
+			require.Nil(t, err)
+			require.Equal(t, start+check.expected, index)
+		})
+	}
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	validateFlagStrict bool
+	validateFlagVars   []string
+)
+
+// Validate checks a config for problems without starting any sessions
+func Validate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate [path]",
+		Short: "Check the automux config for problems",
+		Long: `Check the automux config for problems without starting any sessions
+
+Each problem is printed with its file, line and column along with the path of the field it was
+found in. The exit code is non zero if any errors are found`,
+		Args:         cobra.MaximumNArgs(1),
+		RunE:         validateCmd,
+		SilenceUsage: true,
+	}
+
+	cmd.Flags().BoolVar(&validateFlagStrict, "strict", false, "Treat warnings as errors")
+	cmd.Flags().StringArrayVar(
+		&validateFlagVars,
+		"var",
+		nil,
+		"Set a config variable as key=value, this overrides the value in the configs vars block\n"+
+			"Can be given multiple times",
+	)
+
+	return cmd
+}
+
+func validateCmd(cmd *cobra.Command, args []string) error {
+	configPath, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		configPath = args[0]
+	}
+
+	vars, err := parseVars(validateFlagVars)
+	if err != nil {
+		return err
+	}
+
+	path, problems, err := config.Validate(configPath, vars, !flagNoGlobal)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("no automux config found at %s", configPath)
+		}
+
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var (
+		out      = cmd.OutOrStdout()
+		errCount int
+		warnings int
+	)
+
+	for _, problem := range problems {
+		fmt.Fprintln(out, problem)
+
+		if problem.Warning {
+			warnings++
+		} else {
+			errCount++
+		}
+	}
+
+	if errCount == 0 && warnings == 0 {
+		fmt.Fprintf(out, "%s is valid\n", path)
+		return nil
+	}
+
+	if errCount > 0 || validateFlagStrict {
+		return fmt.Errorf("%d errors, %d warnings", errCount, warnings)
+	}
+
+	fmt.Fprintf(out, "%d warnings\n", warnings)
+	return nil
+}
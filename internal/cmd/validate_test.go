@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// t_runValidate runs the validate command against a config written to a temp dir
+func t_runValidate(t *testing.T, data string, args ...string) (string, error) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, ".automux"), []byte(data), 0644))
+
+	var out bytes.Buffer
+
+	c := Validate()
+	c.SetArgs(append(args, dir))
+	c.SetOut(&out)
+	c.SetErr(&out)
+
+	err := c.Execute()
+
+	return strings.ReplaceAll(out.String(), dir+string(filepath.Separator), ""), err
+}
+
+func TestValidateCmdValid(t *testing.T) {
+	out, err := t_runValidate(t, "version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {}\n")
+
+	require.Nil(t, err)
+	require.Equal(t, ".automux is valid\n", out)
+}
+
+func TestValidateCmdErrors(t *testing.T) {
+	out, err := t_runValidate(t, "version = 1\n\nwindow \"editor\" {\n    split {\n        size = 100\n    }\n}\n")
+
+	require.NotNil(t, err)
+	require.Contains(t, out, `.automux:5:9: error: window "editor" split 1 size: size must be between 0 and 99 (0 means unset), got 100`)
+	require.Contains(t, out, ".automux: error: session_id: session_id is not set")
+}
+
+func TestValidateCmdWarnings(t *testing.T) {
+	config := "version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {\n    dir = \"missing\"\n}\n"
+
+	out, err := t_runValidate(t, config)
+	require.Nil(t, err)
+	require.Equal(t, ".automux:5:5: warning: window \"editor\" dir: directory missing does not exist\n1 warnings\n", out)
+
+	_, err = t_runValidate(t, config, "--strict")
+	require.NotNil(t, err)
+}
+
+func TestValidateCmdVars(t *testing.T) {
+	config := "version = 1\nsession_id = \"${name}\"\n"
+
+	out, err := t_runValidate(t, config)
+	require.NotNil(t, err)
+	require.Contains(t, out, `.automux:2:1: error: session_id: undefined variable "name"`)
+
+	_, err = t_runValidate(t, config, "--var", "name=api")
+	require.Nil(t, err)
+}
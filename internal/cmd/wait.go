@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/indeedhat/automux/internal/config"
+	"github.com/indeedhat/automux/internal/tmux"
+)
+
+const defaultWaitTimeout = 30 * time.Second
+
+// waitInterval is how long to wait between each check of a condition
+var waitInterval = 250 * time.Millisecond
+
+// waitCheck is a single condition from a wait_for block
+type waitCheck struct {
+	description string
+	ready       func() bool
+}
+
+// registerPane records the target of a pane so that wait_for blocks can reference it by name
+func (b *builder) registerPane(name, target string) {
+	if b.panes == nil {
+		b.panes = make(map[string]string)
+	}
+
+	b.panes[name] = target
+}
+
+// waitFor blocks until each of the conditions in the wait_for block are met
+//
+// Any failure (including timing out) is recorded against the element and false is returned so
+// that the exec commands waiting on it are not sent. Dry runs never wait as nothing is started
+func (b *builder) waitFor(element string, wait *config.WaitFor) bool {
+	if wait == nil || tmux.IsDryRun(b.client) {
+		return true
+	}
+
+	if b.abortOnError && b.err.failed() {
+		return false
+	}
+
+	checks, err := b.waitChecks(wait)
+	if err != nil {
+		b.fail(element, err)
+		return false
+	}
+
+	timeout := defaultWaitTimeout
+	if wait.Timeout != nil {
+		timeout = time.Duration(*wait.Timeout) * time.Second
+	}
+
+	deadline := time.Now().Add(timeout)
+	for _, check := range checks {
+		for !check.ready() {
+			if time.Now().After(deadline) {
+				b.fail(element, fmt.Errorf("timed out after %s waiting for %s", timeout, check.description))
+				return false
+			}
+
+			time.Sleep(waitInterval)
+		}
+	}
+
+	return true
+}
+
+// waitChecks builds the checks for each of the conditions set in the wait_for block
+func (b *builder) waitChecks(wait *config.WaitFor) ([]waitCheck, error) {
+	var checks []waitCheck
+
+	if wait.Port != nil && *wait.Port != "" {
+		addr := *wait.Port
+		if !strings.Contains(addr, ":") {
+			addr = "localhost:" + addr
+		}
+
+		checks = append(checks, waitCheck{"port " + addr, func() bool {
+			conn, err := net.DialTimeout("tcp", addr, time.Second)
+			if err != nil {
+				return false
+			}
+
+			conn.Close()
+			return true
+		}})
+	}
+
+	if wait.File != nil && *wait.File != "" {
+		path := *wait.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(b.session.Directory, path)
+		}
+
+		checks = append(checks, waitCheck{"file " + path, func() bool {
+			_, err := os.Stat(path)
+			return err == nil
+		}})
+	}
+
+	if wait.Command != nil && *wait.Command != "" {
+		command := *wait.Command
+
+		checks = append(checks, waitCheck{"command " + command, func() bool {
+			cmd := exec.Command("sh", "-c", command)
+			cmd.Dir = b.session.Directory
+			cmd.Env = os.Environ()
+
+			for key, value := range b.session.Env {
+				cmd.Env = append(cmd.Env, key+"="+value)
+			}
+
+			return cmd.Run() == nil
+		}})
+	}
+
+	if wait.Output != nil && *wait.Output != "" {
+		if wait.Pane == nil || *wait.Pane == "" {
+			return nil, errors.New("wait_for output requires a pane")
+		}
+
+		// ^ and $ match at line boundaries as the output is checked as a whole
+		pattern, err := regexp.Compile("(?m)" + *wait.Output)
+		if err != nil {
+			return nil, fmt.Errorf("wait_for output: %w", err)
+		}
+
+		target, ok := b.panes[*wait.Pane]
+		if !ok {
+			return nil, fmt.Errorf("wait_for unknown pane %q", *wait.Pane)
+		}
+
+		checks = append(checks, waitCheck{fmt.Sprintf("%q in pane %q", *wait.Output, *wait.Pane), func() bool {
+			// -S - includes the history so output that has scrolled out of view still matches
+			out, err := b.client.Output(b.session, "capture-pane", "-p", "-J", "-S", "-", "-t", target)
+			return err == nil && pattern.MatchString(out)
+		}})
+	}
+
+	return checks, nil
+}
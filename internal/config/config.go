@@ -4,8 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -28,22 +28,41 @@ const (
 type Config struct {
 	Version int `icl:"version" json:"version" yaml:"version"`
 	// Used to store the relative directory for the config (if the config is not loaded from the current directory)
-	Directory string
+	Directory string `json:"-" yaml:"-"`
 	// Session id and title for the tmux session
 	SessionId string `icl:"session_id" json:"session_id" yaml:"session_id"`
 	// AttachExisting will cause automux to re attach to any exiting session for thet directory
 	AttachExisting bool `icl:"attach_existing" json:"attach_existing" yaml:"attach_existing"`
 	// ConnfigPath for the tmux.conf file to use on this session
-	ConfigPath string `icl:"config" json:"config" yaml:"config"`
+	ConfigPath string `icl:"config" json:"config,omitempty" yaml:"config,omitempty"`
+	// SocketName selects the tmux server by socket name (tmux -L)
+	SocketName string `icl:"socket_name" json:"socket_name,omitempty" yaml:"socket_name,omitempty"`
+	// SocketPath selects the tmux server by socket path (tmux -S), this takes presedence over SocketName
+	SocketPath string `icl:"socket_path" json:"socket_path,omitempty" yaml:"socket_path,omitempty"`
+	// Include contains the paths of shared configs that this config is merged over, relative paths
+	// are looked up next to the config and then in the users include library
+	Include StringList `icl:"include" json:"include,omitempty" yaml:"include,omitempty"`
+	// Vars contains values that can be referenced as ${name} within the configs fields
+	Vars map[string]string `icl:"vars" json:"vars,omitempty" yaml:"vars,omitempty"`
+	// Env contains environment variables set for every window/split in the session
+	Env map[string]string `icl:"env" json:"env,omitempty" yaml:"env,omitempty"`
+	// EnvFile contains the paths of dotenv files (relative to the session directory) that are
+	// loaded into the session environment, paths starting with - are optional
+	EnvFile StringList `icl:"env_file" json:"env_file,omitempty" yaml:"env_file,omitempty"`
+	// Hooks contains shell commands to run at points in the sessions lifecycle
+	Hooks *Hooks `icl:"hooks" json:"hooks,omitempty" yaml:"hooks,omitempty"`
 	// Windows contains each of the tmux windo defs
 	Windows []Window `icl:"window" json:"windows" yaml:"windows"`
 	// Sessions contains definitions for background sessions to open up
-	Sessions []Session `icl:"session" json:"sessions" yaml:"sessions"`
+	Sessions []Session `icl:"session" json:"sessions,omitempty" yaml:"sessions,omitempty"`
+	// Picker contains the defaults for the pick command, it is only read from the global config
+	Picker *Picker `icl:"picker" json:"picker,omitempty" yaml:"picker,omitempty"`
+	// Discovery controls the search of parent directories for a config, it is only read from the
+	// global config
+	Discovery *Discovery `icl:"discovery" json:"discovery,omitempty" yaml:"discovery,omitempty"`
 
 	// Cli args
-	Detached bool
-	Debug    bool
-	L        *log.Logger
+	Detached bool `json:"-" yaml:"-"`
 }
 
 // AsSession converts the Config instance to a Session one
@@ -53,12 +72,37 @@ func (c *Config) AsSession() Session {
 		SessionId:      c.SessionId,
 		AttachExisting: &c.AttachExisting,
 		ConfigPath:     &c.ConfigPath,
+		SocketName:     c.SocketName,
+		SocketPath:     c.SocketPath,
+		Env:            c.Env,
+		EnvFile:        c.EnvFile,
+		Hooks:          c.Hooks,
 		Windows:        c.Windows,
-		Debug:          c.Debug,
-		L:              c.L,
 	}
 }
 
+// AllSessions returns the master session followed by each of the background sessions
+func (c *Config) AllSessions() []Session {
+	return append([]Session{c.AsSession()}, c.BackgroundSessions()...)
+}
+
+// BackgroundSessions returns the configs background sessions with the tmux server details
+// inherited from the master session for any session that does not select its own
+func (c *Config) BackgroundSessions() []Session {
+	sessions := make([]Session, 0, len(c.Sessions))
+
+	for _, session := range c.Sessions {
+		if session.SocketName == "" && session.SocketPath == "" {
+			session.SocketName = c.SocketName
+			session.SocketPath = c.SocketPath
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions
+}
+
 type Session struct {
 	// Directory to open the session in
 	Directory string `icl:".param" json:"dir" yaml:"dir"`
@@ -69,41 +113,181 @@ type Session struct {
 	// over anything found there
 	//
 	// Session id and title for the tmux session
-	SessionId string `icl:"session_id" json:"session_id" yaml:"session_id"`
+	SessionId string `icl:"session_id" json:"session_id,omitempty" yaml:"session_id,omitempty"`
 	// AttachExisting will cause automux to re attach to any exiting session for thet directory
-	AttachExisting *bool   `icl:"attach_existing" json:"attach_existing" yaml:"attach_existing"`
-	ConfigPath     *string `icl:"config" json:"config" yaml:"config"`
+	AttachExisting *bool   `icl:"attach_existing" json:"attach_existing,omitempty" yaml:"attach_existing,omitempty"`
+	ConfigPath     *string `icl:"config" json:"config,omitempty" yaml:"config,omitempty"`
+	// SocketName selects the tmux server by socket name (tmux -L)
+	// if neither socket field is set the session will use the same server as the master session
+	SocketName string `icl:"socket_name" json:"socket_name,omitempty" yaml:"socket_name,omitempty"`
+	// SocketPath selects the tmux server by socket path (tmux -S)
+	SocketPath string `icl:"socket_path" json:"socket_path,omitempty" yaml:"socket_path,omitempty"`
+	// Env contains environment variables set for every window/split in the session
+	Env map[string]string `icl:"env" json:"env,omitempty" yaml:"env,omitempty"`
+	// EnvFile contains the paths of dotenv files (relative to the session directory) that are
+	// loaded into the session environment, paths starting with - are optional
+	EnvFile StringList `icl:"env_file" json:"env_file,omitempty" yaml:"env_file,omitempty"`
+	// Hooks contains shell commands to run at points in the sessions lifecycle
+	Hooks *Hooks `icl:"hooks" json:"hooks,omitempty" yaml:"hooks,omitempty"`
 	// Windows contains each of the tmux windo defs
-	Windows []Window `icl:"window" json:"windows" yaml:"windows"`
+	Windows []Window `icl:"window" json:"windows,omitempty" yaml:"windows,omitempty"`
+}
+
+// Hooks contains the shell commands run at each point in a sessions lifecycle
+//
+// Commands are run in order in the session directory, each hook stops at the first command that fails
+type Hooks struct {
+	// BeforeCreate is run before the session is created, a failure stops the session from being created
+	BeforeCreate StringList `icl:"before_create" json:"before_create,omitempty" yaml:"before_create,omitempty"`
+	// AfterCreate is run once all of the windows and splits have been opened
+	AfterCreate StringList `icl:"after_create" json:"after_create,omitempty" yaml:"after_create,omitempty"`
+	// OnAttach is run each time automux attaches or switches a client to the session
+	OnAttach StringList `icl:"on_attach" json:"on_attach,omitempty" yaml:"on_attach,omitempty"`
+	// OnKill is run by tmux when the session is closed
+	OnKill StringList `icl:"on_kill" json:"on_kill,omitempty" yaml:"on_kill,omitempty"`
+}
 
-	Debug bool
-	L     *log.Logger
+// WaitFor describes the conditions that must be met before a window/split runs its exec commands
+//
+// When more than one condition is set they must all be met
+type WaitFor struct {
+	// Port waits for a tcp port to accept connections, either a port number on localhost or host:port
+	Port *string `icl:"port" json:"port,omitempty" yaml:"port,omitempty"`
+	// File waits for a file to exist, relative paths are resolved from the session directory
+	File *string `icl:"file" json:"file,omitempty" yaml:"file,omitempty"`
+	// Command waits for a shell command run in the session directory to exit zero
+	Command *string `icl:"command" json:"command,omitempty" yaml:"command,omitempty"`
+	// Output waits for a regex to match the output of the pane named by Pane
+	Output *string `icl:"output" json:"output,omitempty" yaml:"output,omitempty"`
+	// Pane is the name of the split (or the title of the window) whose output is checked
+	Pane *string `icl:"pane" json:"pane,omitempty" yaml:"pane,omitempty"`
+	// Timeout is the number of seconds to wait for the conditions to be met, defaults to 30
+	Timeout *int `icl:"timeout" json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 type Window struct {
 	// Title of the window/tab
 	Title string `icl:".param" json:"title" yaml:"title"`
-	// Cmd contains the command to be run on opening the window
-	Exec *string `icl:"exec" json:"exec" yaml:"exec"`
+	// Exec contains the commands to be run on opening the window, they are sent in order
+	Exec StringList `icl:"exec" json:"exec,omitempty" yaml:"exec,omitempty"`
+	// ExecDelay is the number of milliseconds to wait before sending each exec command after the first
+	ExecDelay *int `icl:"exec_delay" json:"exec_delay,omitempty" yaml:"exec_delay,omitempty"`
+	// ExecAppend adds the exec commands to the end of the ones they override rather than replacing them
+	ExecAppend *bool `icl:"exec_append" json:"exec_append,omitempty" yaml:"exec_append,omitempty"`
+	// WaitFor holds back the exec commands until its conditions are met
+	WaitFor *WaitFor `icl:"wait_for" json:"wait_for,omitempty" yaml:"wait_for,omitempty"`
+	// Run is started as the panes process in place of the default shell, any exec commands are
+	// typed into it once it has started
+	Run *string `icl:"run" json:"run,omitempty" yaml:"run,omitempty"`
+	// RemainOnExit keeps the pane open after its run command exits
+	RemainOnExit *bool `icl:"remain_on_exit" json:"remain_on_exit,omitempty" yaml:"remain_on_exit,omitempty"`
 	// Focus sets the focus to this window after setup is done
-	Focus *bool `icl:"focus" json:"focus" yaml:"focus"`
+	Focus *bool `icl:"focus" json:"focus,omitempty" yaml:"focus,omitempty"`
 	// Sub directory to open the split in
-	Directory *string `icl:"dir" json:"dir" yaml:"dir"`
+	Directory *string `icl:"dir" json:"dir,omitempty" yaml:"dir,omitempty"`
+	// Env contains environment variables for the window and its splits, these are added to the
+	// sessions environment
+	Env map[string]string `icl:"env" json:"env,omitempty" yaml:"env,omitempty"`
 	// Splits contains any extra splits to be opened in this window/tab
-	Splits []Split `icl:"split" json:"splits" yaml:"splits"`
+	Splits []Split `icl:"split" json:"splits,omitempty" yaml:"splits,omitempty"`
+	// Layout is either one of tmux's preset layouts or a raw layout string, it is applied after
+	// the splits have been opened
+	Layout *string `icl:"layout" json:"layout,omitempty" yaml:"layout,omitempty"`
 }
 
 type Split struct {
 	// Vertical defines if the split is vertical or horizontal
-	Vertical *bool `icl:"vertical" json:"vertical" yaml:"vertical"`
-	// Cmd contains any command to be ran when opening the split
-	Exec *string `icl:"exec" json:"exec" yaml:"exec"`
+	Vertical *bool `icl:"vertical" json:"vertical,omitempty" yaml:"vertical,omitempty"`
+	// Exec contains any commands to be ran when opening the split, they are sent in order
+	Exec StringList `icl:"exec" json:"exec,omitempty" yaml:"exec,omitempty"`
+	// ExecDelay is the number of milliseconds to wait before sending each exec command after the first
+	ExecDelay *int `icl:"exec_delay" json:"exec_delay,omitempty" yaml:"exec_delay,omitempty"`
+	// ExecAppend adds the exec commands to the end of the ones they override rather than replacing them
+	ExecAppend *bool `icl:"exec_append" json:"exec_append,omitempty" yaml:"exec_append,omitempty"`
+	// WaitFor holds back the exec commands until its conditions are met
+	WaitFor *WaitFor `icl:"wait_for" json:"wait_for,omitempty" yaml:"wait_for,omitempty"`
+	// Run is started as the panes process in place of the default shell, any exec commands are
+	// typed into it once it has started
+	Run *string `icl:"run" json:"run,omitempty" yaml:"run,omitempty"`
+	// RemainOnExit keeps the pane open after its run command exits
+	RemainOnExit *bool `icl:"remain_on_exit" json:"remain_on_exit,omitempty" yaml:"remain_on_exit,omitempty"`
 	// Size in % of the total screen realestate to take up
-	Size *int `icl:"size" json:"size" yaml:"size"`
+	Size *int `icl:"size" json:"size,omitempty" yaml:"size,omitempty"`
 	// Focus sets the focus to this split after setup is done
-	Focus *bool `icl:"focus" json:"focus" yaml:"focus"`
+	Focus *bool `icl:"focus" json:"focus,omitempty" yaml:"focus,omitempty"`
 	// Sub directory to open the split in
-	Directory *string `icl:"dir" json:"dir" yaml:"dir"`
+	Directory *string `icl:"dir" json:"dir,omitempty" yaml:"dir,omitempty"`
+	// Env contains environment variables for the split and any nested splits, these are added to
+	// the windows environment
+	Env map[string]string `icl:"env" json:"env,omitempty" yaml:"env,omitempty"`
+	// Name labels the splits pane so that other splits can target it
+	Name *string `icl:"name" json:"name,omitempty" yaml:"name,omitempty"`
+	// Target is the name of the split (or the title of the window) whose pane this split will be
+	// opened from, by default the pane opened before it within the same block is used
+	Target *string `icl:"target" json:"target,omitempty" yaml:"target,omitempty"`
+	// Splits contains any splits to be opened from this splits pane
+	Splits []Split `icl:"split" json:"splits,omitempty" yaml:"splits,omitempty"`
+}
+
+// PaneCount returns the number of panes the window will have once all of its splits are open
+func (w Window) PaneCount() int {
+	return 1 + countSplits(w.Splits)
+}
+
+// HasSplitTree checks if any of the windows splits are nested or target a specific pane
+func (w Window) HasSplitTree() bool {
+	for _, split := range w.Splits {
+		if len(split.Splits) > 0 || (split.Target != nil && *split.Target != "") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SplitDirectory resolves the directory a split should be opened in, relative split directories
+// are relative to the windows directory
+func (w Window) SplitDirectory(split Split) string {
+	var dir string
+	if w.Directory != nil {
+		dir = *w.Directory
+	}
+
+	if split.Directory == nil {
+		return dir
+	}
+
+	if dir == "" || path.IsAbs(*split.Directory) {
+		return *split.Directory
+	}
+
+	return path.Join(dir, *split.Directory)
+}
+
+// NestedCount returns the number of splits nested within the split at any depth
+func (s Split) NestedCount() int {
+	return countSplits(s.Splits)
+}
+
+// countSplits counts the splits along with all of their nested splits
+func countSplits(splits []Split) int {
+	count := len(splits)
+	for _, split := range splits {
+		count += countSplits(split.Splits)
+	}
+
+	return count
+}
+
+// Paths returns the path of every supported config file within the given directory
+// in the order they will be checked
+func Paths(dir string) []string {
+	return []string{
+		filepath.Join(dir, DefaultPath),
+		filepath.Join(dir, JsonPath),
+		filepath.Join(dir, YamlPath),
+		filepath.Join(dir, YamlAltPath),
+	}
 }
 
 // Exists checks if an automux config exists in the current directory
@@ -123,125 +307,352 @@ func Exists(path ...string) bool {
 }
 
 // LoadAny loads the first available config from the provided dir
-func LoadAny(path string, logger *log.Logger, debug, detached bool) (*Config, error) {
+//
+// If the dir does not have a config the nearest parent directory with one is used instead, see
+// FindParent. vars override any of the variables defined in the configs vars block, when global
+// is set the users global config is loaded underneath the project config
+func LoadAny(path string, detached bool, vars map[string]string, global bool) (*Config, error) {
+	path, rebase, err := findConfig(path, global)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadValid(path, detached, vars, global, rebase)
+}
+
+// Load loads the config from the given file path
+//
+// vars override any of the variables defined in the configs vars block, when global is set the
+// users global config supplies the defaults for any field the config does not set. Both are
+// passed on to the configs of each of the sub sessions.
+//
+// A *ValidationError is returned if the config has any problems that stop it from being used
+func Load(path string, detached bool, vars map[string]string, global bool) (*Config, error) {
+	return loadValid(path, detached, vars, global, false)
+}
+
+// Validate finds the config for path in the same way as LoadAny and returns the path that was
+// loaded along with every problem found with it, including those that are only warnings
+func Validate(path string, vars map[string]string, global bool) (string, []Problem, error) {
+	path, rebase, err := findConfig(path, global)
+	if err != nil {
+		return path, nil, err
+	}
+
+	_, problems, err := check(path, false, vars, global, rebase)
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return path, validationErr.Problems, nil
+	}
+
+	return path, problems, err
+}
+
+// findConfig resolves the config file to load for path
+//
+// Paths that are not directories are returned as is, rebase is set when the config was found in
+// a parent of the directory
+func findConfig(path string, global bool) (string, bool, error) {
 	stat, err := os.Stat(path)
 	if err != nil || !stat.IsDir() {
-		return Load(path, logger, debug, detached)
+		return path, false, nil
 	}
 
-	path = filepath.Join(path, defaultExt)
+	if !Exists(Paths(path)...) {
+		var discovery *Discovery
+		if global {
+			defaults, err := LoadGlobal()
+			if err != nil {
+				return "", false, err
+			}
 
-	if c, err := Load(path, logger, debug, detached); err == nil {
-		return c, nil
+			if defaults != nil {
+				discovery = defaults.Discovery
+			}
+		}
+
+		parent, ok := FindParent(path, discovery)
+		if !ok {
+			return "", false, os.ErrNotExist
+		}
+
+		// the parent is absolute so the configs directory will be too, this keeps any relative
+		// dirs within it pointing at the right place
+		for _, path := range Paths(parent) {
+			if _, err := os.Stat(path); err == nil {
+				return path, true, nil
+			}
+		}
 	}
 
-	if c, err := Load(path+jsonExt, logger, debug, detached); err == nil {
-		return c, nil
+	for _, path := range Paths(path) {
+		// a config that exists but fails to load is reported rather than skipped over
+		if _, err := os.Stat(path); err == nil {
+			return path, false, nil
+		}
 	}
 
-	if c, err := Load(path+yamlExt, logger, debug, detached); err == nil {
-		return c, nil
+	return "", false, os.ErrNotExist
+}
+
+// loadValid loads the config and fails if any of its problems are errors
+func loadValid(path string, detached bool, vars map[string]string, global, rebase bool) (*Config, error) {
+	c, problems, err := check(path, detached, vars, global, rebase)
+	if err != nil {
+		return nil, err
 	}
 
-	if c, err := Load(path+yamlAltExt, logger, debug, detached); err == nil {
-		return c, nil
+	if hasErrors(problems) {
+		return nil, &ValidationError{Problems: problems}
 	}
 
-	return nil, os.ErrNotExist
+	return c, nil
 }
 
-// Load loads the config from the given file path
-func Load(path string, logger *log.Logger, debug, detached bool) (*Config, error) {
-	c := Config{
-		AttachExisting: true,
+// check loads the config and validates the fields that only apply to the master session
+func check(path string, detached bool, vars map[string]string, global, rebase bool) (*Config, []Problem, error) {
+	c, v, err := load(path, detached, vars, global, rebase)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	ext := filepath.Ext(path)
+	// sub session configs may leave the session id to the session block so this is only checked
+	// once everything has been loaded
+	if c.SessionId == "" {
+		v.errorf("session_id", "session_id is not set")
+	}
 
-	switch ext {
-	case defaultExt:
-		if err := loadICL(path, &c); err != nil {
-			return nil, err
-		}
-	case jsonExt:
-		if err := loadJSON(path, &c); err != nil {
-			return nil, err
+	return c, v.problems, nil
+}
+
+// load loads the config from the given file path along with a validator holding the problems
+// found with it
+//
+// When rebase is set relative sub session directories are resolved from the directory of the
+// config rather than the working directory, this is the case for configs found in a parent of
+// the directory automux was run from
+func load(path string, detached bool, vars map[string]string, global, rebase bool) (*Config, *validator, error) {
+	var (
+		defaults   *Config
+		globalDocs []*document
+	)
+
+	if global {
+		var err error
+		if defaults, globalDocs, err = loadGlobal(); err != nil {
+			return nil, nil, err
 		}
-	case yamlExt, yamlAltExt:
-		if err := loadYAML(path, &c); err != nil {
-			return nil, err
+	}
+
+	// attach_existing cannot be told apart from its default once decoded so the global value is
+	// used as the starting point instead
+	attachExisting := defaults == nil || defaults.AttachExisting
+
+	c, docs, err := loadIncludes(path, nil, attachExisting)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if defaults != nil {
+		c = mergeConfigs(defaults, c)
+	}
+
+	v := newValidator(append(docs, globalDocs...))
+
+	// the session directories are expanded below but problems need them as they were written
+	rawDirs := make([]string, len(c.Sessions))
+	for i, session := range c.Sessions {
+		rawDirs[i] = session.Directory
+	}
+
+	interp, err := newInterpolator(path, c.Vars, vars)
+	if err == nil {
+		err = interp.interpolate(c)
+	}
+
+	if err != nil {
+		if !v.varError(err) {
+			return nil, nil, err
 		}
-	default:
-		return nil, errors.New("Config not found")
+
+		return nil, nil, &ValidationError{Problems: v.problems}
 	}
 
 	// stop spaces from breaking the tmux commands
 	c.SessionId = strings.ReplaceAll(c.SessionId, " ", "-")
-	c.Debug = debug
 	c.Detached = detached
-	c.L = logger
 
-	if path != DefaultPath {
-		c.Directory = strings.TrimSuffix(path, DefaultPath)
+	if dir := configDirectory(path); dir != "" {
+		c.Directory = dir
 	}
 
-	var validSessions []Session
-	for _, session := range c.Sessions {
-		session.L = logger
-		session.Debug = debug
-		sessionConf, err := Load(filepath.Join(session.Directory, ".automux"), logger, debug, detached)
+	var (
+		validSessions []Session
+		blocks        []Session
+	)
+
+	for i, session := range c.Sessions {
+		if rebase && session.Directory != "" && !filepath.IsAbs(session.Directory) {
+			session.Directory = filepath.Join(c.Directory, session.Directory)
+		}
+
+		blocks = append(blocks, session)
+
+		sessionConf, sessionValidator, err := load(filepath.Join(session.Directory, ".automux"), detached, vars, global, rebase)
 		if err != nil {
-			if os.IsNotExist(err) {
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				v.problems = append(v.problems, validationErr.Problems...)
 				validSessions = append(validSessions, session)
+				continue
 			}
-			continue
+
+			if !os.IsNotExist(err) {
+				return nil, nil, fmt.Errorf("session %s: %w", session.Directory, err)
+			}
+		} else {
+			v.problems = append(v.problems, sessionValidator.problems...)
+			session = mergeSessions(sessionConf.AsSession(), session)
 		}
 
-		validSessions = append(validSessions, mergeSessions(sessionConf.AsSession(), session))
+		if session.SessionId == "" {
+			v.warnf(fmt.Sprintf("session %q", rawDirs[i]), "the session has no session_id and will not be started")
+		}
+
+		validSessions = append(validSessions, session)
 	}
 
 	c.Sessions = validSessions
+	v.config(c, blocks, rawDirs)
+
+	return c, v, nil
+}
+
+// configDirectory returns the directory a config file at path lives in, which is used as the
+// directory for the session
+//
+// The directory is taken from the path itself so every config format resolves the same way and
+// the result has no trailing separator. A bare file name is left empty so tmux falls back to the
+// working directory
+func configDirectory(path string) string {
+	if dir := filepath.Dir(path); dir != "." {
+		return dir
+	}
 
-	return &c, nil
+	return ""
 }
 
-func loadICL(path string, c *Config) error {
-	ast, err := icl.ParseFile(path)
+// decode reads the config file at path in the format matching its extension along with a
+// document holding the position of each of its fields
+//
+// attachExisting is used when the config does not set attach_existing itself
+func decode(path string, attachExisting bool) (*Config, *document, error) {
+	switch filepath.Ext(path) {
+	case defaultExt, jsonExt, yamlExt, yamlAltExt:
+	default:
+		return nil, nil, errors.New("Config not found")
+	}
+
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	if err := versionCheck(ast.Version()); err != nil {
-		return err
+	return decodeData(path, data, attachExisting)
+}
+
+// decodeData decodes the contents of a config file in the format matching the extension of path
+func decodeData(path string, data []byte, attachExisting bool) (*Config, *document, error) {
+	c := Config{
+		AttachExisting: attachExisting,
 	}
 
-	return ast.Unmarshal(c)
+	var (
+		doc *document
+		err error
+	)
+
+	switch filepath.Ext(path) {
+	case defaultExt:
+		doc, err = loadICL(path, data, &c)
+	case jsonExt:
+		doc, err = loadJSON(path, data, &c)
+	case yamlExt, yamlAltExt:
+		doc, err = loadYAML(path, data, &c)
+	default:
+		return nil, nil, errors.New("Config not found")
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &c, doc, nil
 }
 
-func loadJSON(path string, c *Config) error {
-	data, err := os.ReadFile(path)
+func loadICL(path string, data []byte, c *Config) (*document, error) {
+	ast, err := icl.Parse(data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := json.Unmarshal(data, c); err != nil {
-		return err
+	root, problems := iclTree(path, data, ast.Nodes)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	if err := versionCheck(ast.Version()); err != nil {
+		return nil, err
 	}
 
-	return versionCheck(c.Version)
+	expandListNodes(ast.Nodes)
+
+	if err := ast.Unmarshal(c); err != nil {
+		return nil, &ValidationError{Problems: []Problem{{File: path, Message: err.Error(), err: err}}}
+	}
+
+	return newDocument(path, "icl", root), nil
 }
 
-func loadYAML(path string, c *Config) error {
-	data, err := os.ReadFile(path)
+func loadJSON(path string, data []byte, c *Config) (*document, error) {
+	if err := json.Unmarshal(data, c); err != nil {
+		if problem, ok := jsonProblem(path, data, err); ok {
+			return nil, &ValidationError{Problems: []Problem{problem}}
+		}
+
+		return nil, err
+	}
+
+	if err := versionCheck(c.Version); err != nil {
+		return nil, err
+	}
+
+	root, err := jsonTree(data)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return newDocument(path, "json", root), nil
+}
+
+func loadYAML(path string, data []byte, c *Config) (*document, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, &ValidationError{Problems: yamlProblems(path, err)}
+	}
+
+	if node.Kind != 0 {
+		if err := node.Decode(c); err != nil {
+			return nil, &ValidationError{Problems: yamlProblems(path, err)}
+		}
 	}
 
-	if err := yaml.Unmarshal(data, c); err != nil {
-		return err
+	if err := versionCheck(c.Version); err != nil {
+		return nil, err
 	}
 
-	return versionCheck(c.Version)
+	return newDocument(path, "yaml", yamlTree(&node)), nil
 }
 
 func versionCheck(version int) error {
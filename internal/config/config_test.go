@@ -1,8 +1,6 @@
 package config
 
 import (
-	"bytes"
-	"log"
 	"os"
 	"testing"
 
@@ -17,7 +15,6 @@ func TestConfigAsSession(t *testing.T) {
 		Windows: []Window{
 			{Title: "automux-test-title"},
 		},
-		Debug: true,
 	}
 
 	s := c.AsSession()
@@ -25,36 +22,30 @@ func TestConfigAsSession(t *testing.T) {
 	require.Equal(t, c.SessionId, s.SessionId)
 	require.Equal(t, c.ConfigPath, *s.ConfigPath)
 	require.Equal(t, c.Windows[0].Title, s.Windows[0].Title)
-	require.Equal(t, c.Debug, s.Debug)
 }
 
 var loadChecks = []struct {
 	name          string
 	path          string
-	debug         bool
 	shouldSucceed bool
 	sessionCount  int
 }{
-	{"single-session", "../../_examples/single_session", true, true, 0},
-	{"multi-session", "../../_examples/multi_session", true, true, 3},
-	{"bad-path", "../../_examples", false, false, 0},
-	{"bad-config", "../../_examples/bad-config", false, false, 0},
+	{"single-session", "../../_examples/single_session", true, 0},
+	{"multi-session", "../../_examples/multi_session", true, 3},
+	{"bad-path", "../../_examples", false, 0},
+	{"bad-config", "../../_examples/bad-config", false, 0},
 }
 
 // TestLoad checks that config is correctly loaded by file path
 func TestLoad(t *testing.T) {
 	testDir, err := os.Getwd()
 	require.Nil(t, err)
-	var (
-		b bytes.Buffer
-		l = log.New(&b, "", 0)
-	)
 
 	for _, check := range loadChecks {
 		t.Run(check.name, func(t *testing.T) {
 			require.Nil(t, os.Chdir(check.path))
 
-			c, err := Load(".automux", l, check.debug, false)
+			c, err := Load(".automux", false, nil, false)
 			if !check.shouldSucceed {
 				require.NotNil(t, err)
 				return
@@ -69,6 +60,26 @@ func TestLoad(t *testing.T) {
 	}
 }
 
+var configDirectoryChecks = []struct {
+	path     string
+	expected string
+}{
+	{"/tmp/proj/.automux", "/tmp/proj"},
+	{"/tmp/proj/.automux.json", "/tmp/proj"},
+	{"/tmp/proj/.automux.yml", "/tmp/proj"},
+	{"sub/.automux", "sub"},
+	{".automux", ""},
+}
+
+// TestConfigDirectory checks that the session directory is the directory of the config for every format
+func TestConfigDirectory(t *testing.T) {
+	for _, check := range configDirectoryChecks {
+		t.Run(check.path, func(t *testing.T) {
+			require.Equal(t, check.expected, configDirectory(check.path))
+		})
+	}
+}
+
 var existsChecks = []struct {
 	name     string
 	path     []string
@@ -87,3 +98,24 @@ func TestExists(t *testing.T) {
 		})
 	}
 }
+
+// TestBackgroundSessions checks that background sessions inherit the masters tmux server
+// unless they select their own
+func TestBackgroundSessions(t *testing.T) {
+	c := &Config{
+		SocketName: "work",
+		Sessions: []Session{
+			{SessionId: "inherits"},
+			{SessionId: "by-name", SocketName: "personal"},
+			{SessionId: "by-path", SocketPath: "/tmp/tmux.sock"},
+		},
+	}
+
+	sessions := c.BackgroundSessions()
+
+	require.Len(t, sessions, 3)
+	require.Equal(t, "work", sessions[0].SocketName)
+	require.Equal(t, "personal", sessions[1].SocketName)
+	require.Equal(t, "", sessions[2].SocketName)
+	require.Equal(t, "/tmp/tmux.sock", sessions[2].SocketPath)
+}
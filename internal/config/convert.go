@@ -0,0 +1,141 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Find resolves the config file that LoadAny would load for path
+func Find(path string, global bool) (string, error) {
+	path, _, err := findConfig(path, global)
+	return path, err
+}
+
+// Convert re-encodes the config file at path in the format matching the extension of target
+//
+// The file is converted as it was written, includes, variables and sub session configs are kept
+// as references rather than being merged in. When comments is set the comments on their own line
+// before each field are carried over if both formats support them, any that are not followed by
+// a field are dropped
+func Convert(path, target string, comments bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c, doc, err := decodeData(path, data, true)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := Encode(c, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if !comments || !hasComments(path) || !hasComments(target) {
+		return out, nil
+	}
+
+	fields := fieldComments(data, doc)
+	if len(fields) == 0 {
+		return out, nil
+	}
+
+	_, outDoc, err := decodeData(target, out, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return insertComments(out, outDoc, fields), nil
+}
+
+// hasComments checks if the format of the config at path supports comments
+func hasComments(path string) bool {
+	return filepath.Ext(path) != jsonExt
+}
+
+// fieldComments finds the comments on their own line directly before each field, keyed by the
+// path of the field
+func fieldComments(data []byte, doc *document) map[string][]string {
+	var (
+		comments = make(map[string][]string)
+		lines    = lineFields(doc)
+		pending  []string
+	)
+
+	for i, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			pending = append(pending, trimmed)
+		case trimmed == "":
+			continue
+		default:
+			if field, ok := lines[i+1]; ok && len(pending) > 0 {
+				comments[field] = append(comments[field], pending...)
+			}
+
+			pending = nil
+		}
+	}
+
+	return comments
+}
+
+// lineFields maps each line to the field that starts on it
+//
+// When more than one field is on a line the first one is used, e.g. for `- title: vim` in yaml
+// the window is used rather than its title
+func lineFields(doc *document) map[int]string {
+	var (
+		lines     = make(map[int]string)
+		positions = make(map[int]Position)
+	)
+
+	for field, pos := range doc.positions {
+		existing, ok := positions[pos.Line]
+		if ok && (existing.Column < pos.Column ||
+			existing.Column == pos.Column && len(lines[pos.Line]) <= len(field)) {
+			continue
+		}
+
+		lines[pos.Line] = field
+		positions[pos.Line] = pos
+	}
+
+	return lines
+}
+
+// insertComments adds the comments above the line each of their fields is on, indented to match
+func insertComments(data []byte, doc *document, comments map[string][]string) []byte {
+	fields := make([]string, 0, len(comments))
+	for field := range comments {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	before := make(map[int][]string)
+	for _, field := range fields {
+		if pos, ok := doc.positions[field]; ok {
+			before[pos.Line] = append(before[pos.Line], comments[field]...)
+		}
+	}
+
+	var lines []string
+	for i, line := range strings.Split(string(data), "\n") {
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+
+		for _, comment := range before[i+1] {
+			lines = append(lines, indent+comment)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
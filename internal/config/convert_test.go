@@ -0,0 +1,103 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var convertDocument = `# shared settings
+version = 1
+session_id = "${name}"
+include = "shared"
+vars = {name: "api"}
+
+# the editor comes first
+window "editor" {
+    exec = "nvim"
+
+    split {
+        # logs are tailed beside the editor
+        run = "tail -f log"
+        size = 30
+    }
+}
+
+window "server" {
+    exec = ["make run"]
+}
+
+session "./web" {
+    window "ui" {}
+}
+`
+
+var convertYaml = `# shared settings
+version: 1
+session_id: ${name}
+attach_existing: true
+include: shared
+vars:
+  name: api
+windows:
+  # the editor comes first
+  - title: editor
+    exec: nvim
+    splits:
+      # logs are tailed beside the editor
+      - run: tail -f log
+        size: 30
+  - title: server
+    exec: make run
+sessions:
+  - dir: ./web
+    windows:
+      - title: ui
+`
+
+// TestConvert checks that a config is converted as written with its order and comments kept
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	t_writeConfigs(t, dir, map[string]string{DefaultPath: convertDocument})
+
+	data, err := Convert(filepath.Join(dir, DefaultPath), YamlPath, true)
+	require.Nil(t, err)
+	require.Equal(t, convertYaml, string(data))
+
+	data, err = Convert(filepath.Join(dir, DefaultPath), YamlPath, false)
+	require.Nil(t, err)
+	require.NotContains(t, string(data), "#")
+
+	data, err = Convert(filepath.Join(dir, DefaultPath), JsonPath, true)
+	require.Nil(t, err)
+	require.NotContains(t, string(data), "#")
+	require.NotContains(t, string(data), "null")
+}
+
+// TestConvertRoundTrip checks that converting a config back to its original format gives the
+// same config
+func TestConvertRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t_writeConfigs(t, dir, map[string]string{DefaultPath: convertDocument})
+
+	expected, _, err := decode(filepath.Join(dir, DefaultPath), true)
+	require.Nil(t, err)
+
+	for _, path := range []string{YamlPath, JsonPath} {
+		data, err := Convert(filepath.Join(dir, DefaultPath), path, true)
+		require.Nil(t, err)
+		t_writeConfigs(t, dir, map[string]string{path: string(data)})
+
+		data, err = Convert(filepath.Join(dir, path), DefaultPath, true)
+		require.Nil(t, err)
+		if path == YamlPath {
+			require.Contains(t, string(data), "    # logs are tailed beside the editor\n    split {\n")
+		}
+		t_writeConfigs(t, dir, map[string]string{"back.automux": string(data)})
+
+		c, _, err := decode(filepath.Join(dir, "back.automux"), true)
+		require.Nil(t, err, path)
+		require.Equal(t, expected, c, path)
+	}
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Discovery controls the search of parent directories for a config when there is not one in the
+// directory automux was run from, it is only read from the global config
+type Discovery struct {
+	// Enabled turns the search on or off, defaults to true
+	Enabled *bool `icl:"enabled" json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// StopAtHome stops the search at the users home directory, defaults to true
+	StopAtHome *bool `icl:"stop_at_home" json:"stop_at_home,omitempty" yaml:"stop_at_home,omitempty"`
+	// StopAtGit stops the search at the root of a git repository, defaults to true
+	StopAtGit *bool `icl:"stop_at_git" json:"stop_at_git,omitempty" yaml:"stop_at_git,omitempty"`
+	// StopAtMount stops the search rather than crossing onto another filesystem, defaults to true
+	StopAtMount *bool `icl:"stop_at_mount" json:"stop_at_mount,omitempty" yaml:"stop_at_mount,omitempty"`
+}
+
+// FindParent searches the parents of dir for the nearest directory containing a config
+//
+// dir itself is not checked. The search stops after checking the users home directory or the
+// root of a git repository and will not cross onto another filesystem, each of these can be
+// turned off in the discovery settings
+func FindParent(dir string, discovery *Discovery) (string, bool) {
+	if discovery == nil {
+		discovery = &Discovery{}
+	}
+
+	if !enabled(discovery.Enabled) {
+		return "", false
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+
+	home, _ := os.UserHomeDir()
+
+	for {
+		if enabled(discovery.StopAtHome) && home != "" && dir == home {
+			return "", false
+		}
+
+		if enabled(discovery.StopAtGit) && Exists(filepath.Join(dir, ".git")) {
+			return "", false
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+
+		if enabled(discovery.StopAtMount) && !sameDevice(dir, parent) {
+			return "", false
+		}
+
+		dir = parent
+		if Exists(Paths(dir)...) {
+			return dir, true
+		}
+	}
+}
+
+// enabled reads an optional setting that defaults to true
+func enabled(setting *bool) bool {
+	return setting == nil || *setting
+}
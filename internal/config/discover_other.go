@@ -0,0 +1,10 @@
+//go:build !unix
+
+package config
+
+// sameDevice checks if both paths are on the same filesystem
+//
+// The device is not available outside of unix so every path is treated as being on the same one
+func sameDevice(a, b string) bool {
+	return true
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var findParentChecks = []struct {
+	name      string
+	start     string
+	discovery *Discovery
+	expected  string
+}{
+	{"nearest", "project/src/pkg", nil, "project"},
+	{"nested", "project/nested/src", nil, "project/nested"},
+	{"git-root", "repo/src", nil, ""},
+	{"git-root-disabled", "repo/src", &Discovery{StopAtGit: t_ptr(false)}, "."},
+	{"home", "home/user/src", nil, ""},
+	{"home-disabled", "home/user/src", &Discovery{StopAtHome: t_ptr(false)}, "."},
+	{"disabled", "project/src/pkg", &Discovery{Enabled: t_ptr(false)}, ""},
+}
+
+// TestFindParent checks that the nearest parent directory with a config is found
+func TestFindParent(t *testing.T) {
+	root := t.TempDir()
+
+	t.Setenv("HOME", filepath.Join(root, "home", "user"))
+
+	t_writeConfigs(t, root, map[string]string{
+		DefaultPath:                   "version = 1\n",
+		"project/.automux.yml":        "version: 1\n",
+		"project/nested/.automux":     "version = 1\n",
+		"repo/.git/HEAD":              "ref: refs/heads/main\n",
+		"project/src/pkg/.gitkeep":    "",
+		"project/nested/src/.gitkeep": "",
+		"repo/src/.gitkeep":           "",
+		"home/user/src/.gitkeep":      "",
+	})
+
+	for _, check := range findParentChecks {
+		t.Run(check.name, func(t *testing.T) {
+			dir, ok := FindParent(filepath.Join(root, check.start), check.discovery)
+			if check.expected == "" {
+				require.False(t, ok, dir)
+				return
+			}
+
+			require.True(t, ok)
+			require.Equal(t, filepath.Join(root, check.expected), dir)
+		})
+	}
+}
+
+// TestLoadAnyParent checks that a config found in a parent directory is loaded with its directory
+// set to where it was found
+func TestLoadAnyParent(t *testing.T) {
+	root := t.TempDir()
+
+	t_writeConfigs(t, root, map[string]string{
+		DefaultPath: `
+version = 1
+session_id = "root"
+
+window "api" {
+    dir = "api"
+}
+
+session "./api" {}
+`,
+		"api/.automux":     "version = 1\nsession_id = \"api\"\n",
+		"src/pkg/.gitkeep": "",
+	})
+
+	c, err := LoadAny(filepath.Join(root, "src", "pkg"), false, nil, false)
+	require.Nil(t, err)
+
+	require.Equal(t, "root", c.SessionId)
+	require.Equal(t, root, c.Directory)
+	require.Equal(t, "api", *c.Windows[0].Directory)
+
+	require.Len(t, c.Sessions, 1)
+	require.Equal(t, "api", c.Sessions[0].SessionId)
+	require.Equal(t, filepath.Join(root, "api"), c.Sessions[0].Directory)
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+// sameDevice checks if both paths are on the same filesystem
+func sameDevice(a, b string) bool {
+	statA, errA := os.Stat(a)
+	statB, errB := os.Stat(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	sysA, okA := statA.Sys().(*syscall.Stat_t)
+	sysB, okB := statB.Sys().(*syscall.Stat_t)
+	if !okA || !okB {
+		return true
+	}
+
+	return sysA.Dev == sysB.Dev
+}
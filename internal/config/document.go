@@ -0,0 +1,699 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/indeedhat/icl"
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a 1 based line and column within a config file
+type Position struct {
+	Line   int
+	Column int
+}
+
+// document records where each field of a config file is defined
+//
+// Fields are keyed by the same paths used in problems, e.g. `window "vim" split 2 size`
+type document struct {
+	file      string
+	positions map[string]Position
+	// problems holds the warnings found while indexing the file, such as unknown fields
+	problems []Problem
+}
+
+// rawNode is a format independent view of a parsed config file used to index field positions
+type rawNode struct {
+	pos Position
+	// value holds the value of scalar nodes
+	value any
+	// param holds the parameter of an icl block
+	param  *string
+	fields []rawField
+	items  []*rawNode
+	array  bool
+}
+
+// rawField is a single named field within a rawNode
+type rawField struct {
+	name string
+	pos  Position
+	node *rawNode
+}
+
+// field finds the first field with the given name
+func (n *rawNode) field(name string) *rawNode {
+	for _, f := range n.fields {
+		if f.name == name {
+			return f.node
+		}
+	}
+
+	return nil
+}
+
+var (
+	windowType  = reflect.TypeOf(Window{})
+	splitType   = reflect.TypeOf(Split{})
+	sessionType = reflect.TypeOf(Session{})
+)
+
+// indexer walks a rawNode alongside the config type it is decoded into
+type indexer struct {
+	doc *document
+	// tag is the struct tag used to match field names for the format being indexed
+	tag string
+}
+
+// newDocument indexes the fields of a parsed config file
+func newDocument(file, tag string, root *rawNode) *document {
+	doc := &document{file: file, positions: make(map[string]Position)}
+	if root != nil {
+		idx := indexer{doc: doc, tag: tag}
+		idx.walk(root, reflect.TypeOf(Config{}), nil)
+	}
+
+	return doc
+}
+
+// walk records the position of each field in the node, fields that do not exist in the config
+// type are reported as warnings
+func (idx indexer) walk(node *rawNode, t reflect.Type, path []string) {
+	counts := make(map[string]int)
+
+	for _, f := range node.fields {
+		if len(path) == 0 && f.name == schemaKey {
+			continue
+		}
+
+		sf, ok := structField(t, idx.tag, f.name)
+		if !ok {
+			idx.doc.problems = append(idx.doc.problems, Problem{
+				File:     idx.doc.file,
+				Position: f.pos,
+				Field:    strings.Join(append(path[:len(path):len(path)], f.name), " "),
+				Message:  fmt.Sprintf("unknown field %q", f.name),
+				Warning:  true,
+			})
+			continue
+		}
+
+		name := iclName(sf)
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() == reflect.Struct {
+			items := f.node.items
+			if !f.node.array {
+				// icl blocks are repeated rather than being wrapped in a list
+				items = []*rawNode{f.node}
+			}
+
+			for _, item := range items {
+				counts[name]++
+				itemPath := elementPath(path, ft.Elem(), item, counts[name])
+				idx.doc.positions[strings.Join(itemPath, " ")] = item.pos
+				idx.walk(item, ft.Elem(), itemPath)
+			}
+
+			continue
+		}
+
+		fieldPath := append(path[:len(path):len(path)], name)
+		idx.doc.positions[strings.Join(fieldPath, " ")] = f.pos
+
+		if ft.Kind() == reflect.Struct && !f.node.array {
+			idx.walk(f.node, ft, fieldPath)
+		}
+	}
+}
+
+// elementPath builds the path of a window, split or session
+//
+// windows and sessions are named by their title/directory and splits by their position, nested
+// splits continue their parents number e.g. split 2.1
+func elementPath(path []string, t reflect.Type, item *rawNode, number int) []string {
+	switch t {
+	case splitType:
+		if len(path) > 0 && strings.HasPrefix(path[len(path)-1], "split ") {
+			parent := path[len(path)-1]
+			return append(path[:len(path)-1:len(path)-1], fmt.Sprintf("%s.%d", parent, number))
+		}
+
+		return append(path[:len(path):len(path)], fmt.Sprintf("split %d", number))
+	case windowType:
+		return append(path[:len(path):len(path)], fmt.Sprintf("window %q", paramValue(item, "title")))
+	case sessionType:
+		return append(path[:len(path):len(path)], fmt.Sprintf("session %q", paramValue(item, "dir")))
+	}
+
+	return path
+}
+
+// paramValue finds the value of an icl block parameter or the equivalent json/yaml field
+func paramValue(item *rawNode, field string) string {
+	if item.param != nil {
+		return *item.param
+	}
+
+	if node := item.field(field); node != nil {
+		if str, ok := node.value.(string); ok {
+			return str
+		}
+	}
+
+	return ""
+}
+
+// structField finds the field of the struct type that the name decodes into for the given tag
+func structField(t reflect.Type, tag, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		key, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if key == "" || key == "-" || key == ".param" {
+			continue
+		}
+
+		if key == name {
+			return sf, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// iclName returns the name used for the field in problem paths
+func iclName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("icl"), ",")
+	if name == "" || name == ".param" {
+		name, _, _ = strings.Cut(sf.Tag.Get("json"), ",")
+	}
+
+	return name
+}
+
+// iclTree converts the icl ast into a rawNode
+//
+// The icl parser does not report syntax errors so anything it could not make sense of is
+// returned as a problem
+func iclTree(file string, data []byte, nodes []icl.Node) (*rawNode, []Problem) {
+	var (
+		problems  = iclBrackets(file, data)
+		positions = newICLPositions(data)
+	)
+
+	root := &rawNode{pos: Position{1, 1}}
+
+	var convert func(parent *rawNode, nodes []icl.Node)
+	convert = func(parent *rawNode, nodes []icl.Node) {
+		for _, node := range nodes {
+			if iclNil(node) {
+				problems = append(problems, Problem{File: file, Position: parent.pos, Message: "expected `name = value`"})
+				continue
+			}
+
+			switch n := node.(type) {
+			case *icl.AssignNode:
+				if iclNil(n.Name) {
+					problems = append(problems, Problem{
+						File:     file,
+						Position: positions.node(n),
+						Message:  "expected `name = value`",
+					})
+					continue
+				}
+
+				pos := positions.of(n.Name.Token)
+				if _, ok := n.Value.(*icl.Identifier); ok || iclNil(n.Value) {
+					problems = append(problems, Problem{
+						File:     file,
+						Position: pos,
+						Field:    n.Name.Value,
+						Message:  "expected a value",
+					})
+					continue
+				}
+
+				value, valueProblems := positions.value(file, n.Name.Value, n.Value, pos)
+				problems = append(problems, valueProblems...)
+				parent.fields = append(parent.fields, rawField{n.Name.Value, pos, value})
+			case *icl.BlockNode:
+				pos := positions.of(n.Token)
+				block := &rawNode{pos: pos}
+				if len(n.Parameters) > 0 {
+					block.param = &n.Parameters[0].Literal
+				}
+
+				if n.Body != nil {
+					convert(block, n.Body.Nodes)
+				}
+
+				parent.fields = append(parent.fields, rawField{n.Token.Literal, pos, block})
+			case *icl.CollectionNode:
+				convert(parent, n.Elements)
+			default:
+				problems = append(problems, Problem{
+					File:     file,
+					Position: positions.node(node),
+					Message:  fmt.Sprintf("unexpected %s", node.String()),
+				})
+			}
+		}
+	}
+
+	convert(root, nodes)
+
+	slices.SortStableFunc(problems, func(a, b Problem) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+
+		return a.Column - b.Column
+	})
+
+	return root, problems
+}
+
+// iclNil checks if the node is missing, the parser leaves typed nil nodes behind for input it
+// could not parse
+func iclNil(node icl.Node) bool {
+	if node == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(node)
+
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// iclPositions maps the line and position the icl lexer gives each token to its real position
+//
+// The lexer does not count the newline at the end of a comment or those within strings so any
+// token after one of them has its line and column thrown off
+type iclPositions map[[2]int]Position
+
+// newICLPositions follows the lexers line counting through the file to map each of the positions
+// it reports back to the real one
+func newICLPositions(data []byte) iclPositions {
+	var (
+		positions = make(iclPositions)
+		lex       [2]int
+		real      = Position{1, 1}
+		quote     byte
+		escaped   bool
+		comment   bool
+	)
+
+	for _, c := range data {
+		positions[lex] = real
+
+		// newlines are only counted by the lexer when they are skipped as whitespace
+		counted := c == '\n'
+		switch {
+		case quote != 0:
+			counted = false
+			if c == quote && !escaped {
+				quote = 0
+			}
+			escaped = c == '\\'
+		case comment:
+			counted = false
+			comment = c != '\n'
+		case c == '#':
+			comment = true
+		case c == '"' || c == '\'':
+			quote = c
+		}
+
+		if counted {
+			lex = [2]int{lex[0] + 1, 0}
+		} else {
+			lex[1]++
+		}
+
+		if c == '\n' {
+			real = Position{real.Line + 1, 1}
+		} else {
+			real.Column++
+		}
+	}
+
+	return positions
+}
+
+// of finds the real position of an icl token
+func (p iclPositions) of(tok icl.Token) Position {
+	if tok.Type == icl.TknString {
+		// strings are positioned from their end less the length of the unquoted value so the quotes
+		// and the backslash of each escaped quote have to be added back
+		tok.Pos -= 2 + strings.Count(tok.Literal, `"`)
+	}
+
+	if pos, ok := p[[2]int{tok.Line, tok.Pos}]; ok {
+		return pos
+	}
+
+	return Position{Line: tok.Line + 1, Column: tok.Pos + 1}
+}
+
+// node finds the position of any icl node that has a token
+func (p iclPositions) node(node icl.Node) Position {
+	v := reflect.Indirect(reflect.ValueOf(node))
+	if v.Kind() != reflect.Struct {
+		return Position{}
+	}
+
+	field := v.FieldByName("Token")
+	if !field.IsValid() {
+		return Position{}
+	}
+
+	if tok, ok := field.Interface().(icl.Token); ok && tok.Type != "" {
+		return p.of(tok)
+	}
+
+	return Position{}
+}
+
+// value converts the icl value node of the named field into a rawNode
+//
+// Elements of a list that the parser could not make sense of are left out and returned as
+// problems at the position of the field holding the list, the parser does not keep the position
+// of the opening bracket
+func (p iclPositions) value(file, name string, node icl.Node, pos Position) (*rawNode, []Problem) {
+	switch n := node.(type) {
+	case *icl.StringNode:
+		return &rawNode{pos: pos, value: n.Value}, nil
+	case *icl.SliceNode:
+		var (
+			list     = &rawNode{pos: pos, array: true}
+			problems []Problem
+		)
+
+		for _, elem := range n.Elements {
+			if iclNil(elem) {
+				problems = append(problems, Problem{File: file, Position: pos, Field: name, Message: "expected a list value"})
+				continue
+			}
+
+			item, itemProblems := p.value(file, name, elem, pos)
+			list.items = append(list.items, item)
+			problems = append(problems, itemProblems...)
+		}
+
+		return list, problems
+	case *icl.MapNode:
+		// map keys are user defined so are not indexed
+		return &rawNode{pos: pos, array: true}, nil
+	default:
+		return &rawNode{pos: pos, value: node.String()}, nil
+	}
+}
+
+// iclBrackets checks that the braces and brackets in an icl file are balanced and that every
+// string is closed
+func iclBrackets(file string, data []byte) []Problem {
+	var (
+		stack    []openBracket
+		problems []Problem
+		pos      = Position{1, 1}
+	)
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		here := pos
+
+		if c == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+
+		switch c {
+		case '#':
+			for i+1 < len(data) && data[i+1] != '\n' {
+				i++
+				pos.Column++
+			}
+		case '"', '\'':
+			closed := false
+			for i+1 < len(data) {
+				i++
+				if data[i] == '\n' {
+					pos.Line++
+					pos.Column = 1
+					continue
+				}
+
+				pos.Column++
+				if data[i] == '\\' && i+1 < len(data) && data[i+1] == c {
+					i++
+					pos.Column++
+					continue
+				}
+				if data[i] == c {
+					closed = true
+					break
+				}
+			}
+
+			if !closed {
+				problems = append(problems, Problem{File: file, Position: here, Message: "unterminated string"})
+			}
+		case '{', '[':
+			stack = append(stack, openBracket{c, here})
+		case '}', ']':
+			expected := byte('{')
+			if c == ']' {
+				expected = '['
+			}
+
+			// a closer that matches an opener further down closes it along with anything left open
+			// within it, otherwise it has nothing to close
+			match := len(stack) - 1
+			for match >= 0 && stack[match].char != expected {
+				match--
+			}
+
+			if match < 0 {
+				problems = append(problems, Problem{File: file, Position: here, Message: fmt.Sprintf("unexpected %c", c)})
+				continue
+			}
+
+			problems = append(problems, unclosed(file, stack[match+1:])...)
+			stack = stack[:match]
+		}
+	}
+
+	return append(problems, unclosed(file, stack)...)
+}
+
+// openBracket is a brace or bracket that iclBrackets has not seen closed yet
+type openBracket struct {
+	char byte
+	pos  Position
+}
+
+// unclosed reports each of the brackets that was opened but never closed
+func unclosed(file string, opened []openBracket) []Problem {
+	var problems []Problem
+
+	for _, o := range opened {
+		closing := '}'
+		if o.char == '[' {
+			closing = ']'
+		}
+
+		problems = append(problems, Problem{File: file, Position: o.pos, Message: fmt.Sprintf("%c is never closed with %c", o.char, closing)})
+	}
+
+	return problems
+}
+
+// jsonTree parses json into a rawNode with the position of each value
+func jsonTree(data []byte) (*rawNode, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	return jsonValue(dec, data)
+}
+
+// jsonValue reads the next json value from the decoder
+func jsonValue(dec *json.Decoder, data []byte) (*rawNode, error) {
+	pos := offsetPosition(data, skipJSONSpace(data, dec.InputOffset()))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return &rawNode{pos: pos, value: tok}, nil
+	}
+
+	node := &rawNode{pos: pos, array: delim == '['}
+	for dec.More() {
+		if node.array {
+			item, err := jsonValue(dec, data)
+			if err != nil {
+				return nil, err
+			}
+
+			node.items = append(node.items, item)
+			continue
+		}
+
+		keyPos := offsetPosition(data, skipJSONSpace(data, dec.InputOffset()))
+		key, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := jsonValue(dec, data)
+		if err != nil {
+			return nil, err
+		}
+
+		node.fields = append(node.fields, rawField{fmt.Sprint(key), keyPos, value})
+	}
+
+	// closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// skipJSONSpace moves the offset past any whitespace and separators to the start of the next token
+func skipJSONSpace(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) >= 0 {
+		offset++
+	}
+
+	return offset
+}
+
+// offsetPosition converts a byte offset into a line and column
+func offsetPosition(data []byte, offset int64) Position {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+
+	return Position{Line: line, Column: column}
+}
+
+// jsonProblem converts a json decode error into a positioned problem
+func jsonProblem(file string, data []byte, err error) (Problem, bool) {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+
+	switch {
+	case errors.As(err, &syntaxErr):
+		// the offset is just past the character that could not be parsed
+		return Problem{
+			File:     file,
+			Position: offsetPosition(data, max(syntaxErr.Offset-1, 0)),
+			Message:  syntaxErr.Error(),
+		}, true
+	case errors.As(err, &typeErr):
+		return Problem{
+			File:     file,
+			Position: offsetPosition(data, typeErr.Offset),
+			Field:    typeErr.Field,
+			Message:  fmt.Sprintf("expected %s but got %s", typeErr.Type, typeErr.Value),
+		}, true
+	}
+
+	return Problem{}, false
+}
+
+// yamlTree converts a yaml node into a rawNode
+func yamlTree(node *yaml.Node) *rawNode {
+	if node == nil {
+		return nil
+	}
+
+	if node.Kind == yaml.DocumentNode {
+		if len(node.Content) == 0 {
+			return nil
+		}
+
+		return yamlTree(node.Content[0])
+	}
+
+	raw := &rawNode{pos: Position{Line: node.Line, Column: node.Column}}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			raw.fields = append(raw.fields, rawField{
+				name: key.Value,
+				pos:  Position{Line: key.Line, Column: key.Column},
+				node: yamlTree(node.Content[i+1]),
+			})
+		}
+	case yaml.SequenceNode:
+		raw.array = true
+		for _, item := range node.Content {
+			raw.items = append(raw.items, yamlTree(item))
+		}
+	case yaml.AliasNode:
+		return yamlTree(node.Alias)
+	default:
+		raw.value = node.Value
+	}
+
+	return raw
+}
+
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlProblems converts a yaml decode error into positioned problems
+func yamlProblems(file string, err error) []Problem {
+	var (
+		messages []string
+		typeErr  *yaml.TypeError
+	)
+
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	} else {
+		messages = []string{err.Error()}
+	}
+
+	problems := make([]Problem, 0, len(messages))
+	for _, message := range messages {
+		problem := Problem{File: file, Message: strings.TrimPrefix(message, "yaml: ")}
+
+		if match := yamlLinePattern.FindStringSubmatch(message); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Message = match[2]
+		}
+
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
@@ -0,0 +1,237 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Environment returns the sessions environment variables with the contents of its env files
+// loaded underneath them, variables set directly in the config take presedence over the files
+func (s Session) Environment() (map[string]string, error) {
+	if len(s.EnvFile) == 0 {
+		return s.Env, nil
+	}
+
+	fileEnv, err := LoadEnvFiles(s.Directory, s.EnvFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return MergeEnv(fileEnv, s.Env), nil
+}
+
+// LoadEnvFiles reads each of the dotenv files in order with later files overriding earlier ones
+//
+// Relative paths are resolved from dir. Paths starting with a - are optional and are skipped if
+// the file does not exist
+func LoadEnvFiles(dir string, paths []string) (map[string]string, error) {
+	env := make(map[string]string)
+
+	for _, path := range paths {
+		path, optional := strings.CutPrefix(path, "-")
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if optional && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			return nil, fmt.Errorf("env file: %w", err)
+		}
+
+		if err := parseEnv(string(data), env); err != nil {
+			return nil, fmt.Errorf("env file %s: %w", path, err)
+		}
+	}
+
+	return env, nil
+}
+
+// parseEnv parses the contents of a dotenv file into env
+//
+// Lines take the form KEY=value with an optional export prefix, # starts a comment. Single quoted
+// values are taken literally, double quoted values support escapes and can span multiple lines.
+// $VAR, ${VAR} and ${VAR:-default} are expanded in double quoted and unquoted values using the
+// variables already in env followed by the automux process environment
+func parseEnv(data string, env map[string]string) error {
+	lines := strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		number := i + 1
+
+		line := strings.TrimSpace(lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "export"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimLeft(rest, " \t")
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected KEY=value", number)
+		}
+
+		key = strings.TrimSpace(key)
+		if !envKeyPattern.MatchString(key) {
+			return fmt.Errorf("line %d: invalid variable name %q", number, key)
+		}
+
+		value = strings.TrimLeft(value, " \t")
+
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			env[key] = expandEnv(stripComment(value), env, false)
+			continue
+		}
+
+		quote := value[0]
+		body := value[1:]
+
+		end := closingQuote(body, quote)
+		for end < 0 && i+1 < len(lines) {
+			i++
+			body += "\n" + lines[i]
+			end = closingQuote(body, quote)
+		}
+
+		if end < 0 {
+			return fmt.Errorf("line %d: missing closing %c", number, quote)
+		}
+
+		if rest := strings.TrimSpace(body[end+1:]); rest != "" && rest[0] != '#' {
+			return fmt.Errorf("line %d: unexpected %q after closing %c", number, rest, quote)
+		}
+
+		if quote == '\'' {
+			env[key] = body[:end]
+		} else {
+			env[key] = expandEnv(body[:end], env, true)
+		}
+	}
+
+	return nil
+}
+
+// stripComment removes any trailing comment from an unquoted value, a # only starts a comment at
+// the start of the value or when there is whitespace before it
+func stripComment(value string) string {
+	for i := 0; i < len(value); i++ {
+		if value[i] == '#' && (i == 0 || value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+
+	return strings.TrimSpace(value)
+}
+
+// closingQuote finds the index of the quote that closes the value, backslash escapes are skipped
+// within double quotes
+func closingQuote(value string, quote byte) int {
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && quote == '"':
+			i++
+		case value[i] == quote:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// expandEnv replaces the variables within the value, escapes are only processed for double
+// quoted values
+func expandEnv(value string, env map[string]string, escapes bool) string {
+	var buf strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		if c == '\\' && escapes && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			case 'r':
+				buf.WriteByte('\r')
+			case '"', '\\', '$':
+				buf.WriteByte(value[i])
+			default:
+				buf.WriteByte('\\')
+				buf.WriteByte(value[i])
+			}
+
+			continue
+		}
+
+		if c != '$' || i+1 == len(value) {
+			buf.WriteByte(c)
+			continue
+		}
+
+		if value[i+1] == '{' {
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				buf.WriteByte(c)
+				continue
+			}
+
+			name, fallback, _ := strings.Cut(value[i+2:i+end], ":-")
+			if val := lookupEnv(name, env); val != "" {
+				buf.WriteString(val)
+			} else {
+				buf.WriteString(fallback)
+			}
+
+			i += end
+			continue
+		}
+
+		name := envNameAt(value[i+1:])
+		if name == "" {
+			buf.WriteByte(c)
+			continue
+		}
+
+		buf.WriteString(lookupEnv(name, env))
+		i += len(name)
+	}
+
+	return buf.String()
+}
+
+// envNameAt returns the variable name at the start of the value
+func envNameAt(value string) string {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+
+		return value[:i]
+	}
+
+	return value
+}
+
+// lookupEnv finds the value of a variable in env falling back to the process environment
+func lookupEnv(name string, env map[string]string) string {
+	if val, ok := env[name]; ok {
+		return val
+	}
+
+	return os.Getenv(name)
+}
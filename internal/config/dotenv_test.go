@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var parseEnvChecks = []struct {
+	name     string
+	data     string
+	expected map[string]string
+}{
+	{"plain", "A=1\nB = two\n", map[string]string{"A": "1", "B": "two"}},
+	{"comments", "# comment\n\nA=1 # trailing\nB=a#b\nC=#\n", map[string]string{"A": "1", "B": "a#b", "C": ""}},
+	{"export", "export A=1\nexport\tB=2\nexported=3\n", map[string]string{"A": "1", "B": "2", "exported": "3"}},
+	{"single-quotes", `A='$HOME # not a comment \n'`, map[string]string{"A": `$HOME # not a comment \n`}},
+	{"double-quotes", `A="one\ttwo \"three\" \$4" # comment`, map[string]string{"A": "one\ttwo \"three\" $4"}},
+	{"multi-line", "A=\"one\ntwo\"\nB='three\nfour'\n", map[string]string{"A": "one\ntwo", "B": "three\nfour"}},
+	{
+		"expansion",
+		"A=one\nB=${A}-$A\nC=\"${MISSING:-fallback} $AUTOMUX_TEST_ENV\"\nD=$\n",
+		map[string]string{"A": "one", "B": "one-one", "C": "fallback process", "D": "$"},
+	},
+	{"crlf", "A=1\r\nB=2\r\n", map[string]string{"A": "1", "B": "2"}},
+}
+
+// TestParseEnv checks the dotenv parsing rules
+func TestParseEnv(t *testing.T) {
+	t.Setenv("AUTOMUX_TEST_ENV", "process")
+
+	for _, check := range parseEnvChecks {
+		t.Run(check.name, func(t *testing.T) {
+			env := make(map[string]string)
+			require.Nil(t, parseEnv(check.data, env))
+			require.Equal(t, check.expected, env)
+		})
+	}
+}
+
+var parseEnvErrorChecks = []struct {
+	name string
+	data string
+}{
+	{"no-value", "A\n"},
+	{"bad-name", "1A=1\n"},
+	{"unclosed", "A=\"one\n"},
+	{"after-quote", "A='one' two\n"},
+}
+
+func TestParseEnvErrors(t *testing.T) {
+	for _, check := range parseEnvErrorChecks {
+		t.Run(check.name, func(t *testing.T) {
+			require.NotNil(t, parseEnv(check.data, make(map[string]string)))
+		})
+	}
+}
+
+// TestSessionEnvironment checks that env files are loaded in order underneath the configs env
+func TestSessionEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("A=1\nB=1\nC=1\n"), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, ".env.local"), []byte("B=2\nC=${A}2\n"), 0644))
+
+	session := Session{
+		Directory: dir,
+		Env:       map[string]string{"C": "3"},
+		EnvFile:   StringList{".env", "-.env.local", "-.env.missing"},
+	}
+
+	env, err := session.Environment()
+	require.Nil(t, err)
+	require.Equal(t, map[string]string{"A": "1", "B": "2", "C": "3"}, env)
+
+	session.EnvFile = StringList{".env.missing"}
+	_, err = session.Environment()
+	require.NotNil(t, err)
+}
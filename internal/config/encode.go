@@ -0,0 +1,151 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/indeedhat/icl"
+	"gopkg.in/yaml.v3"
+)
+
+// Encode encodes the config in the format matching the extension of the given path
+func Encode(c *Config, path string) ([]byte, error) {
+	switch ext := filepath.Ext(path); ext {
+	case defaultExt:
+		return encodeICL(c)
+	case jsonExt:
+		data, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return append(data, '\n'), nil
+	case yamlExt, yamlAltExt:
+		var buf bytes.Buffer
+
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(c); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), enc.Close()
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+}
+
+// encodeICL encodes the config as icl
+//
+// The icl encoder writes out every field so any unset fields are stripped from the output
+func encodeICL(c *Config) ([]byte, error) {
+	enc, err := icl.NewEncoder(*c)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, err := enc.Encode(*c)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, node := range compactNodes(ast.Nodes, true) {
+		switch n := node.(type) {
+		case *icl.CollectionNode:
+			// give each top level window/session block some room
+			for _, elem := range n.Elements {
+				buf.WriteString("\n" + elem.String() + "\n")
+			}
+		case *icl.BlockNode:
+			buf.WriteString("\n" + n.String() + "\n")
+		default:
+			buf.WriteString(node.String() + "\n")
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// compactNodes removes null assignments, empty lists/maps and empty blocks from the nodes
+//
+// Empty strings are only removed when dropEmpty is set, within windows and splits every field is
+// a pointer so an empty string is a deliberate value (such as clearing an exec in an override)
+func compactNodes(nodes []icl.Node, dropEmpty bool) []icl.Node {
+	var compact []icl.Node
+
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *icl.AssignNode:
+			if _, ok := n.Value.(*icl.NullNode); ok {
+				continue
+			}
+
+			if str, ok := n.Value.(*icl.StringNode); ok && dropEmpty && str.Value == "" {
+				continue
+			}
+
+			if list, ok := n.Value.(*icl.SliceNode); ok {
+				if len(list.Elements) == 0 {
+					continue
+				}
+
+				// single entry lists are written back out as the plain string they can be given as
+				if len(list.Elements) == 1 && iclListFields[n.Name.Value] {
+					n.Value = list.Elements[0]
+				}
+			}
+
+			if m, ok := n.Value.(*icl.MapNode); ok {
+				if len(m.Elements) == 0 {
+					continue
+				}
+
+				n.Value = inlineMap{m}
+			}
+		case *icl.BlockNode:
+			n.Body.Nodes = compactNodes(n.Body.Nodes, dropEmpty)
+			if len(n.Body.Nodes) == 0 {
+				continue
+			}
+		case *icl.CollectionNode:
+			if len(n.Elements) == 0 {
+				continue
+			}
+
+			for _, elem := range n.Elements {
+				if block, ok := elem.(*icl.BlockNode); ok {
+					block.Body.Nodes = compactNodes(block.Body.Nodes, block.Token.Literal == "session")
+				}
+			}
+		}
+
+		compact = append(compact, node)
+	}
+
+	return compact
+}
+
+// inlineMap writes an icl map on a single line
+//
+// The icl parser loses track of the document when a map spanning multiple lines is nested
+// within a block so maps are always written inline
+type inlineMap struct {
+	*icl.MapNode
+}
+
+// String implements icl.Node
+func (m inlineMap) String() string {
+	pairs := make([]string, 0, len(m.Elements))
+	for key, value := range m.Elements {
+		pairs = append(pairs, key.String()+": "+value.String())
+	}
+
+	sort.Strings(pairs)
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var encodeChecks = []struct {
+	name string
+	path string
+	load func(string, []byte, *Config) (*document, error)
+}{
+	{"icl", DefaultPath, loadICL},
+	{"json", JsonPath, loadJSON},
+	{"yaml", YamlPath, loadYAML},
+	{"yaml-alt", YamlAltPath, loadYAML},
+}
+
+// TestEncode checks that an encoded config loads back to the same values in every format
+func TestEncode(t *testing.T) {
+	var (
+		exec     = "nvim"
+		empty    = ""
+		focus    = true
+		size     = 30
+		dir      = "api"
+		expected = Config{
+			Version:        1,
+			SessionId:      "automux-test-encode",
+			AttachExisting: true,
+			SocketName:     "work",
+			Env:            map[string]string{"APP_ENV": "dev", "PORT": "8080"},
+			EnvFile:        StringList{".env", "-.env.local"},
+			Hooks:          &Hooks{BeforeCreate: StringList{"docker compose up -d"}, OnKill: StringList{"docker compose stop"}},
+			Windows: []Window{
+				{Title: "editor", Exec: StringList{exec}, Focus: &focus, Env: map[string]string{"EDITOR": "nvim"}},
+				{
+					Title:     "steps",
+					Exec:      StringList{"cd api", "make run"},
+					ExecDelay: &size,
+					WaitFor:   &WaitFor{Port: &dir, Output: &exec, Pane: &dir, Timeout: &size},
+				},
+				{Title: "cmd", Directory: &dir, Splits: []Split{{Vertical: &focus, Size: &size}, {Run: &exec, RemainOnExit: &focus}}},
+				{Title: "tree", Splits: []Split{
+					{Name: &dir, Splits: []Split{{Exec: StringList{exec}, Env: map[string]string{"A": "1"}}, {Splits: []Split{{}}}}},
+					{Target: &dir},
+				}},
+			},
+			Sessions: []Session{
+				{
+					Directory: "./sub/",
+					Hooks:     &Hooks{OnAttach: StringList{"git fetch", "git status -s"}},
+					Windows:   []Window{{Title: "editor", Exec: StringList{empty}}},
+				},
+			},
+		}
+	)
+
+	for _, check := range encodeChecks {
+		t.Run(check.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), check.path)
+
+			data, err := Encode(&expected, path)
+			require.Nil(t, err)
+			require.NotContains(t, string(data), "null")
+			require.Nil(t, os.WriteFile(path, data, 0644))
+
+			var c Config
+			_, err = check.load(path, data, &c)
+			require.Nil(t, err)
+			require.Equal(t, expected, c)
+		})
+	}
+}
+
+func TestEncodeUnsupported(t *testing.T) {
+	_, err := Encode(&Config{}, "config.toml")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Picker contains the defaults for the pick command, it is only read from the global config
+type Picker struct {
+	// Roots are the directories scanned for projects when no --root is given
+	Roots StringList `icl:"roots" json:"roots,omitempty" yaml:"roots,omitempty"`
+	// Depth is how many levels below each root to scan
+	Depth *int `icl:"depth" json:"depth,omitempty" yaml:"depth,omitempty"`
+	// Ignore contains glob patterns for directory names to skip
+	Ignore StringList `icl:"ignore" json:"ignore,omitempty" yaml:"ignore,omitempty"`
+}
+
+// GlobalPaths returns the path of every supported global config in the order they will be checked
+//
+// The global config lives in automux/config within the users config dir
+func GlobalPaths() []string {
+	dir := configDir()
+	if dir == "" {
+		return nil
+	}
+
+	path := filepath.Join(dir, "automux", "config")
+
+	return []string{path + defaultExt, path + jsonExt, path + yamlExt, path + yamlAltExt}
+}
+
+// configDir returns $XDG_CONFIG_HOME or ~/.config when it is not set
+//
+// os.UserConfigDir is not used as it points outside of ~/.config on macos
+func configDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, ".config")
+}
+
+// LoadGlobal loads the first global config that exists, nil is returned if there is not one
+//
+// Variables in the global config are not expanded until it is merged with a project config so
+// that ${dir} refers to the project
+func LoadGlobal() (*Config, error) {
+	c, _, err := loadGlobal()
+	return c, err
+}
+
+// loadGlobal loads the global config along with the documents it was loaded from
+func loadGlobal() (*Config, []*document, error) {
+	for _, path := range GlobalPaths() {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		c, docs, err := loadIncludes(path, nil, true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("global config: %w", err)
+		}
+
+		return c, docs, nil
+	}
+
+	return nil, nil, nil
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var globalDocument = `
+version = 1
+config = "~/.config/tmux/work.conf"
+attach_existing = false
+env = {"EDITOR": "nvim"}
+
+hooks {
+    on_attach = "git fetch"
+}
+
+window "git" {
+    exec = "lazygit"
+    dir = "${dir}"
+}
+
+picker {
+    roots = ["~/projects", "~/work"]
+}
+`
+
+var globalChecks = []struct {
+	name           string
+	project        string
+	global         bool
+	configPath     string
+	attachExisting bool
+	titles         []string
+}{
+	{
+		"defaults",
+		"version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {\n    exec = \"nvim\"\n}\n",
+		true,
+		"~/.config/tmux/work.conf",
+		false,
+		[]string{"git", "editor"},
+	},
+	{
+		"overridden",
+		"version = 1\nsession_id = \"api\"\nconfig = \"./tmux.conf\"\nattach_existing = true\n",
+		true,
+		"./tmux.conf",
+		true,
+		[]string{"git"},
+	},
+	{
+		"no-global",
+		"version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {\n    exec = \"nvim\"\n}\n",
+		false,
+		"",
+		true,
+		[]string{"editor"},
+	},
+}
+
+// TestLoadGlobal checks that the global config supplies the defaults for project configs
+func TestLoadGlobal(t *testing.T) {
+	library := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", library)
+	t_writeConfigs(t, library, map[string]string{"automux/config.automux": globalDocument})
+
+	global, err := LoadGlobal()
+	require.Nil(t, err)
+	require.Equal(t, StringList{"~/projects", "~/work"}, global.Picker.Roots)
+
+	for _, check := range globalChecks {
+		t.Run(check.name, func(t *testing.T) {
+			dir := t.TempDir()
+			t_writeConfigs(t, dir, map[string]string{DefaultPath: check.project})
+
+			c, err := LoadAny(dir, false, nil, check.global)
+			require.Nil(t, err)
+
+			var titles []string
+			for _, window := range c.Windows {
+				titles = append(titles, window.Title)
+			}
+
+			require.Equal(t, "api", c.SessionId)
+			require.Equal(t, check.configPath, c.ConfigPath)
+			require.Equal(t, check.attachExisting, c.AttachExisting)
+			require.Equal(t, check.titles, titles)
+
+			if check.global {
+				require.Equal(t, dir, *c.Windows[0].Directory)
+				require.Equal(t, StringList{"git fetch"}, c.Hooks.OnAttach)
+			}
+		})
+	}
+}
+
+// TestLoadGlobalMissing checks that there being no global config is not an error
+func TestLoadGlobalMissing(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(t.TempDir(), "missing"))
+
+	global, err := LoadGlobal()
+	require.Nil(t, err)
+	require.Nil(t, global)
+}
+
+// TestGlobalPathsHome checks that the global config falls back to ~/.config without $XDG_CONFIG_HOME
+func TestGlobalPathsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	require.Equal(t, filepath.Join(home, ".config", "automux", "config.automux"), GlobalPaths()[0])
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// LibraryDir returns the directory that includes are looked up in when they are not found next to
+// the config, this is automux/include within the users config dir
+func LibraryDir() string {
+	dir := configDir()
+	if dir == "" {
+		return ""
+	}
+
+	return filepath.Join(dir, "automux", "include")
+}
+
+// loadIncludes decodes the config at path and merges it over each of the configs it includes
+//
+// Includes are merged in order with later ones taking presedence, chain holds the configs that
+// led to this one being included so that cycles and failures can be reported with the full chain.
+// attachExisting is only used for the config at path as the includes never set it. The documents
+// of every config that was loaded are returned in order of presedence
+func loadIncludes(path string, chain []string, attachExisting bool) (*Config, []*document, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if slices.Contains(chain, abs) {
+		return nil, nil, fmt.Errorf("include cycle: %s", includeChain(chain, abs))
+	}
+
+	c, doc, err := decode(path, attachExisting)
+	if err != nil {
+		if len(chain) == 0 {
+			return nil, nil, err
+		}
+
+		return nil, nil, fmt.Errorf("%s: %w", includeChain(chain, abs), err)
+	}
+
+	if len(c.Include) == 0 {
+		return c, []*document{doc}, nil
+	}
+
+	var (
+		base     *Config
+		next     = append(chain[:len(chain):len(chain)], abs)
+		included [][]*document
+	)
+
+	for _, include := range c.Include {
+		includePath, err := resolveInclude(filepath.Dir(abs), include)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", includeChain(next), err)
+		}
+
+		includeConf, includeDocs, err := loadIncludes(includePath, next, true)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		included = append(included, includeDocs)
+
+		if base == nil {
+			base = includeConf
+		} else {
+			base = mergeConfigs(base, includeConf)
+		}
+	}
+
+	docs := []*document{doc}
+	for i := len(included) - 1; i >= 0; i-- {
+		docs = append(docs, included[i]...)
+	}
+
+	return mergeConfigs(base, c), docs, nil
+}
+
+// resolveInclude finds the file for an include
+//
+// Relative paths are checked next to the including config before the include library, a path
+// without an extension will also match a config with any of the supported extensions
+func resolveInclude(dir, include string) (string, error) {
+	var dirs []string
+	if filepath.IsAbs(include) {
+		dirs = []string{""}
+	} else {
+		dirs = []string{dir}
+		if library := LibraryDir(); library != "" {
+			dirs = append(dirs, library)
+		}
+	}
+
+	names := []string{include}
+	if filepath.Ext(include) == "" {
+		names = append(names, include+defaultExt, include+jsonExt, include+yamlExt, include+yamlAltExt)
+	}
+
+	for _, dir := range dirs {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+				return path, nil
+			}
+		}
+	}
+
+	if filepath.IsAbs(include) {
+		return "", fmt.Errorf("include %q not found", include)
+	}
+
+	return "", fmt.Errorf("include %q not found in %s", include, strings.Join(dirs, " or "))
+}
+
+// includeChain formats the chain of includes for error messages
+func includeChain(chain []string, path ...string) string {
+	return strings.Join(append(chain[:len(chain):len(chain)], path...), " -> ")
+}
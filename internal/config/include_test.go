@@ -0,0 +1,135 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// t_writeConfigs writes each of the files into dir
+func t_writeConfigs(t *testing.T, dir string, files map[string]string) {
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		require.Nil(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.Nil(t, os.WriteFile(path, []byte(data), 0644))
+	}
+}
+
+// TestLoadInclude checks that included configs are merged underneath the config that includes them
+func TestLoadInclude(t *testing.T) {
+	var (
+		dir     = t.TempDir()
+		library = t.TempDir()
+	)
+
+	t.Setenv("XDG_CONFIG_HOME", library)
+
+	t_writeConfigs(t, dir, map[string]string{
+		DefaultPath: `
+version = 1
+session_id = "api"
+include = ["shared", "team/tests"]
+env = {"PORT": "9090"}
+
+window "editor" {
+    exec = "nvim ."
+}
+
+window "server" {
+    run = "make run PORT=${port}"
+}
+`,
+		"shared.automux": `
+version = 1
+session_id = "shared"
+config = "./tmux.conf"
+vars = {port: "8080"}
+env = {"PORT": "8080", "APP_ENV": "dev"}
+
+window "editor" {
+    exec = "nvim"
+    focus = true
+}
+
+window "git" {
+    exec = "lazygit"
+}
+`,
+	})
+
+	t_writeConfigs(t, filepath.Join(library, "automux", "include"), map[string]string{
+		"team/tests.yml": "version: 1\nwindows:\n- title: tests\n  exec: make test\n",
+	})
+
+	c, err := Load(filepath.Join(dir, DefaultPath), false, nil, false)
+	require.Nil(t, err)
+
+	require.Equal(t, "api", c.SessionId)
+	require.Equal(t, "./tmux.conf", c.ConfigPath)
+	require.Equal(t, map[string]string{"PORT": "9090", "APP_ENV": "dev"}, c.Env)
+
+	var titles []string
+	for _, window := range c.Windows {
+		titles = append(titles, window.Title)
+	}
+
+	require.Equal(t, []string{"editor", "git", "tests", "server"}, titles)
+	require.Equal(t, StringList{"nvim ."}, c.Windows[0].Exec)
+	require.True(t, *c.Windows[0].Focus)
+	require.Equal(t, "make run PORT=8080", *c.Windows[3].Run)
+}
+
+var includeErrorChecks = []struct {
+	name  string
+	files map[string]string
+	err   string
+}{
+	{
+		"cycle",
+		map[string]string{
+			DefaultPath: "version = 1\ninclude = \"a.automux\"\n",
+			"a.automux": "version = 1\ninclude = \"b.automux\"\n",
+			"b.automux": "version = 1\ninclude = \"a.automux\"\n",
+		},
+		"include cycle: {dir}/.automux -> {dir}/a.automux -> {dir}/b.automux -> {dir}/a.automux",
+	},
+	{
+		"missing",
+		map[string]string{
+			DefaultPath: "version = 1\ninclude = \"a.automux\"\n",
+			"a.automux": "version = 1\ninclude = \"missing\"\n",
+		},
+		`{dir}/.automux -> {dir}/a.automux: include "missing" not found in {dir} or {library}/automux/include`,
+	},
+	{
+		"invalid",
+		map[string]string{
+			DefaultPath: "version = 1\ninclude = \"a.json\"\n",
+			"a.json":    `{"windows": []}`,
+		},
+		"{dir}/.automux -> {dir}/a.json: you are using an old config format",
+	},
+}
+
+// TestLoadIncludeErrors checks that include failures are reported along with the include chain
+func TestLoadIncludeErrors(t *testing.T) {
+	for _, check := range includeErrorChecks {
+		t.Run(check.name, func(t *testing.T) {
+			var (
+				dir     = t.TempDir()
+				library = t.TempDir()
+			)
+
+			t.Setenv("XDG_CONFIG_HOME", library)
+			t_writeConfigs(t, dir, check.files)
+
+			_, err := LoadAny(dir, false, nil, false)
+
+			expected := strings.NewReplacer("{dir}", dir, "{library}", library).Replace(check.err)
+			require.ErrorContains(t, err, expected)
+		})
+	}
+}
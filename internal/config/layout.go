@@ -0,0 +1,139 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// presetLayouts contains the names of the layouts built into tmux
+var presetLayouts = map[string]bool{
+	"even-horizontal":          true,
+	"even-vertical":            true,
+	"main-horizontal":          true,
+	"main-horizontal-mirrored": true,
+	"main-vertical":            true,
+	"main-vertical-mirrored":   true,
+	"tiled":                    true,
+}
+
+var (
+	layoutChecksumPattern = regexp.MustCompile(`^[0-9a-f]{4},`)
+	layoutCellPattern     = regexp.MustCompile(`^\d+x\d+,\d+,\d+`)
+	layoutPaneIdPattern   = regexp.MustCompile(`^,\d+`)
+)
+
+// IsPresetLayout checks if the layout is one of the named layouts built into tmux
+func IsPresetLayout(layout string) bool {
+	return presetLayouts[layout]
+}
+
+// LayoutPaneCount parses a raw tmux layout string (as produced by #{window_layout}) and returns
+// the number of panes it describes
+func LayoutPaneCount(layout string) (int, error) {
+	body := layoutChecksumPattern.ReplaceAllString(layout, "")
+
+	count, rest, err := parseLayoutCell(body)
+	if err != nil {
+		return 0, fmt.Errorf("invalid layout %q: %w", layout, err)
+	}
+
+	if rest != "" {
+		return 0, fmt.Errorf("invalid layout %q: unexpected %q", layout, rest)
+	}
+
+	return count, nil
+}
+
+// LayoutWithChecksum adds the checksum tmux requires to the front of a raw layout string if
+// it does not already have one
+func LayoutWithChecksum(layout string) string {
+	if IsPresetLayout(layout) || layoutChecksumPattern.MatchString(layout) {
+		return layout
+	}
+
+	// this is the same checksum tmux uses in layout_checksum
+	var csum uint16
+	for _, c := range []byte(layout) {
+		csum = (csum >> 1) + ((csum & 1) << 15)
+		csum += uint16(c)
+	}
+
+	return fmt.Sprintf("%04x,%s", csum, layout)
+}
+
+// ValidateLayout checks that the windows layout is either a preset or a raw layout with a pane
+// for the window and each of its splits
+func (w Window) ValidateLayout() error {
+	if w.Layout == nil || *w.Layout == "" || IsPresetLayout(*w.Layout) {
+		return nil
+	}
+
+	count, err := LayoutPaneCount(*w.Layout)
+	if err != nil {
+		return err
+	}
+
+	if expected := w.PaneCount(); count != expected {
+		return fmt.Errorf("layout has %d panes but the window has %d", count, expected)
+	}
+
+	return nil
+}
+
+// parseLayoutCell parses a single layout cell and any cells nested within it
+//
+// cells take the form WxH,X,Y followed by either an optional ,ID for a pane or a comma separated
+// list of cells wrapped in {} or [] for a container
+func parseLayoutCell(layout string) (int, string, error) {
+	dims := layoutCellPattern.FindString(layout)
+	if dims == "" {
+		return 0, "", errors.New("expected WxH,X,Y")
+	}
+
+	layout = layout[len(dims):]
+
+	// like tmux a number followed by an x is the start of the next cell rather than a pane id
+	if id := layoutPaneIdPattern.FindString(layout); id != "" && !strings.HasPrefix(layout[len(id):], "x") {
+		return 1, layout[len(id):], nil
+	}
+
+	if layout == "" || (layout[0] != '{' && layout[0] != '[') {
+		return 1, layout, nil
+	}
+
+	closing := byte('}')
+	if layout[0] == '[' {
+		closing = ']'
+	}
+
+	var (
+		count int
+		rest  = layout[1:]
+	)
+
+	for {
+		n, r, err := parseLayoutCell(rest)
+		if err != nil {
+			return 0, "", err
+		}
+
+		count += n
+		rest = r
+
+		if rest == "" {
+			return 0, "", fmt.Errorf("missing closing %c", closing)
+		}
+
+		if rest[0] == closing {
+			return count, rest[1:], nil
+		}
+
+		if rest[0] != ',' {
+			return 0, "", fmt.Errorf("unexpected %q", rest)
+		}
+
+		rest = rest[1:]
+	}
+}
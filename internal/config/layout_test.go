@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var layoutPaneCountChecks = []struct {
+	name   string
+	layout string
+	panes  int
+	valid  bool
+}{
+	{"single", "b25d,80x24,0,0,0", 1, true},
+	{"no-checksum", "80x24,0,0,0", 1, true},
+	{"nested", "adc3,159x48,0,0{79x48,0,0,0,79x48,80,0[79x24,80,0,1,79x23,80,25,2]}", 3, true},
+	{"siblings", "159x48,0,0[159x24,0,0,0,159x23,0,25{79x23,0,25,1,79x23,80,25,2}]", 3, true},
+	{"unclosed", "159x48,0,0{79x48,0,0,0,79x48,80,0", 0, false},
+	{"trailing", "80x24,0,0,0}", 0, false},
+	{"garbage", "not a layout", 0, false},
+	{"no-pane-id", "80x24,0,0", 1, true},
+	{"no-pane-ids", "159x48,0,0{79x48,0,0,79x48,80,0[79x24,80,0,79x23,80,25]}", 3, true},
+	{"mixed-pane-ids", "159x48,0,0{79x48,0,0,79x48,80,0,5}", 2, true},
+	{"pane-id-then-cell", "159x48,0,0{79x48,0,0,12,79x48,80,0}", 2, true},
+	{"bad-cell", "159x48,0,0{79x48,0,0,7x}", 0, false},
+}
+
+func TestLayoutPaneCount(t *testing.T) {
+	for _, check := range layoutPaneCountChecks {
+		t.Run(check.name, func(t *testing.T) {
+			panes, err := LayoutPaneCount(check.layout)
+			if !check.valid {
+				require.NotNil(t, err)
+				return
+			}
+
+			require.Nil(t, err)
+			require.Equal(t, check.panes, panes)
+		})
+	}
+}
+
+// TestLayoutWithChecksum checks the checksum matches the one tmux generates
+func TestLayoutWithChecksum(t *testing.T) {
+	const layout = "adc3,159x48,0,0{79x48,0,0,0,79x48,80,0[79x24,80,0,1,79x23,80,25,2]}"
+
+	require.Equal(t, layout, LayoutWithChecksum(layout[5:]))
+	require.Equal(t, layout, LayoutWithChecksum(layout))
+	require.Equal(t, "tiled", LayoutWithChecksum("tiled"))
+}
+
+func TestWindowValidateLayout(t *testing.T) {
+	require.Nil(t, Window{}.ValidateLayout())
+	require.Nil(t, Window{Layout: t_ptr("main-vertical"), Splits: []Split{{}, {}, {}}}.ValidateLayout())
+	require.Nil(t, Window{Layout: t_ptr("80x24,0,0{40x24,0,0,0,39x24,41,0,1}"), Splits: []Split{{}}}.ValidateLayout())
+	require.NotNil(t, Window{Layout: t_ptr("80x24,0,0{40x24,0,0,0,39x24,41,0,1}")}.ValidateLayout())
+	require.NotNil(t, Window{Layout: t_ptr("main-sideways")}.ValidateLayout())
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+
+	"github.com/indeedhat/icl"
+	"gopkg.in/yaml.v3"
+)
+
+// StringList is a list of strings that can also be written as a single string in the config
+type StringList []string
+
+// UnmarshalJSON implements json.Unmarshaler
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = StringList{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*l = list
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+//
+// Lists with a single entry are written as a plain string
+func (l StringList) MarshalJSON() ([]byte, error) {
+	if len(l) == 1 {
+		return json.Marshal(l[0])
+	}
+
+	return json.Marshal([]string(l))
+}
+
+// MarshalYAML implements yaml.Marshaler
+//
+// Lists with a single entry are written as a plain string
+func (l StringList) MarshalYAML() (any, error) {
+	if len(l) == 1 {
+		return l[0], nil
+	}
+
+	return []string(l), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var single string
+		if err := node.Decode(&single); err != nil {
+			return err
+		}
+
+		*l = StringList{single}
+		return nil
+	}
+
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+
+	*l = list
+	return nil
+}
+
+// iclListFields contains the icl names of every StringList field
+var iclListFields = map[string]bool{
+	"include":       true,
+	"exec":          true,
+	"env_file":      true,
+	"before_create": true,
+	"after_create":  true,
+	"on_attach":     true,
+	"on_kill":       true,
+	"roots":         true,
+	"ignore":        true,
+}
+
+// expandListNodes wraps single strings assigned to StringList fields in a list
+//
+// The icl decoder has no hook for custom types so this is done on the ast before it is decoded
+func expandListNodes(nodes []icl.Node) {
+	for _, node := range nodes {
+		switch n := node.(type) {
+		case *icl.AssignNode:
+			if str, ok := n.Value.(*icl.StringNode); ok && iclListFields[n.Name.Value] {
+				n.Value = &icl.SliceNode{Token: str.Token, Elements: []icl.Node{str}}
+			}
+		case *icl.BlockNode:
+			expandListNodes(n.Body.Nodes)
+		}
+	}
+}
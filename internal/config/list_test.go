@@ -0,0 +1,50 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var stringListChecks = []struct {
+	name string
+	path string
+	data string
+}{
+	{"icl", DefaultPath, "version = 1\nsession_id = \"list\"\nenv_file = \".env\"\n"},
+	{"json", JsonPath, `{"version": 1, "session_id": "list", "env_file": ".env"}`},
+	{"yaml", YamlPath, "version: 1\nsession_id: list\nenv_file: .env\n"},
+}
+
+// TestStringList checks that a single string can be given in place of a list
+func TestStringList(t *testing.T) {
+	for _, check := range stringListChecks {
+		t.Run(check.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), check.path)
+			require.Nil(t, os.WriteFile(path, []byte(check.data), 0644))
+
+			c, err := Load(path, false, nil, false)
+			require.Nil(t, err)
+			require.Equal(t, StringList{".env"}, c.EnvFile)
+		})
+	}
+}
+
+// TestStringListMarshal checks that single entry lists are written back out as a plain string
+func TestStringListMarshal(t *testing.T) {
+	data, err := json.Marshal(StringList{"make run"})
+	require.Nil(t, err)
+	require.Equal(t, `"make run"`, string(data))
+
+	data, err = json.Marshal(StringList{"cd api", "make run"})
+	require.Nil(t, err)
+	require.Equal(t, `["cd api","make run"]`, string(data))
+
+	data, err = yaml.Marshal(StringList{"make run"})
+	require.Nil(t, err)
+	require.Equal(t, "make run\n", string(data))
+}
@@ -15,11 +15,110 @@ func mergeSessions(target, override Session) Session {
 	if override.AttachExisting != nil {
 		target.AttachExisting = override.AttachExisting
 	}
+	if override.SocketName != "" || override.SocketPath != "" {
+		target.SocketName = override.SocketName
+		target.SocketPath = override.SocketPath
+	}
+
+	if len(override.EnvFile) > 0 {
+		target.EnvFile = override.EnvFile
+	}
 
+	target.Hooks = mergeHooks(target.Hooks, override.Hooks)
+	target.Env = MergeEnv(target.Env, override.Env)
 	target.Windows = mergeWindows(target.Windows, override.Windows)
 	return target
 }
 
+// mergeConfigs merges a config over one of the configs it includes
+//
+// Fields set in the override take presedence, windows are merged by title following the same
+// rules as mergeSessions and the sessions of both configs are kept
+func mergeConfigs(target, override *Config) *Config {
+	merged := *override
+
+	if merged.SessionId == "" {
+		merged.SessionId = target.SessionId
+	}
+	if merged.ConfigPath == "" {
+		merged.ConfigPath = target.ConfigPath
+	}
+	if merged.SocketName == "" && merged.SocketPath == "" {
+		merged.SocketName = target.SocketName
+		merged.SocketPath = target.SocketPath
+	}
+	if len(merged.EnvFile) == 0 {
+		merged.EnvFile = target.EnvFile
+	}
+
+	merged.Vars = MergeEnv(target.Vars, override.Vars)
+	merged.Env = MergeEnv(target.Env, override.Env)
+	merged.Hooks = mergeHooks(target.Hooks, override.Hooks)
+	merged.Windows = mergeWindows(target.Windows, override.Windows)
+	merged.Sessions = append(target.Sessions[:len(target.Sessions):len(target.Sessions)], override.Sessions...)
+
+	return &merged
+}
+
+// mergeHooks replaces each of the target hooks that are set in the override
+func mergeHooks(target, override *Hooks) *Hooks {
+	if override == nil {
+		return target
+	}
+	if target == nil {
+		return override
+	}
+
+	merged := *target
+	if len(override.BeforeCreate) > 0 {
+		merged.BeforeCreate = override.BeforeCreate
+	}
+	if len(override.AfterCreate) > 0 {
+		merged.AfterCreate = override.AfterCreate
+	}
+	if len(override.OnAttach) > 0 {
+		merged.OnAttach = override.OnAttach
+	}
+	if len(override.OnKill) > 0 {
+		merged.OnKill = override.OnKill
+	}
+
+	return &merged
+}
+
+// MergeEnv combines two sets of environment variables with the override values taking presedence
+//
+// A new map is always returned so neither of the inputs are modified
+func MergeEnv(target, override map[string]string) map[string]string {
+	if len(target) == 0 && len(override) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string, len(target)+len(override))
+	for key, value := range target {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+
+	return merged
+}
+
+// mergeExec replaces the target exec commands with the override ones, unless appendSteps is set
+// in which case the override commands are added to the end of the target ones
+func mergeExec(target, override StringList, appendSteps *bool) StringList {
+	if override == nil {
+		return target
+	}
+
+	if appendSteps != nil && *appendSteps {
+		return append(target[:len(target):len(target)], override...)
+	}
+
+	return override
+}
+
 // mergeWindows merges two winow slices
 //
 // Merges are based on window titles
@@ -41,8 +140,18 @@ func mergeWindows(target, override []Window) []Window {
 				continue
 			}
 
-			if window.Exec != nil {
-				final.Exec = window.Exec
+			final.Exec = mergeExec(final.Exec, window.Exec, window.ExecAppend)
+			if window.ExecDelay != nil {
+				final.ExecDelay = window.ExecDelay
+			}
+			if window.WaitFor != nil {
+				final.WaitFor = window.WaitFor
+			}
+			if window.Run != nil {
+				final.Run = window.Run
+			}
+			if window.RemainOnExit != nil {
+				final.RemainOnExit = window.RemainOnExit
 			}
 			if window.Focus != nil {
 				final.Focus = window.Focus
@@ -50,6 +159,11 @@ func mergeWindows(target, override []Window) []Window {
 			if window.Directory != nil {
 				final.Directory = window.Directory
 			}
+			if window.Layout != nil {
+				final.Layout = window.Layout
+			}
+
+			final.Env = MergeEnv(final.Env, window.Env)
 
 			final.Splits = mergeSplits(final.Splits, window.Splits)
 
@@ -71,6 +185,7 @@ func mergeWindows(target, override []Window) []Window {
 //   - When a slice in the overrides shares an index with target it will have any non nil
 //     fields replaced in the target split by the override one
 //   - Extra slices will be appenden
+//   - Nested splits are merged the same way within each pair of merged splits
 func mergeSplits(target, override []Split) []Split {
 	var (
 		extras []Split
@@ -85,8 +200,18 @@ func mergeSplits(target, override []Split) []Split {
 
 		final := &target[i]
 
-		if split.Exec != nil {
-			(*final).Exec = split.Exec
+		(*final).Exec = mergeExec(final.Exec, split.Exec, split.ExecAppend)
+		if split.ExecDelay != nil {
+			(*final).ExecDelay = split.ExecDelay
+		}
+		if split.WaitFor != nil {
+			(*final).WaitFor = split.WaitFor
+		}
+		if split.Run != nil {
+			(*final).Run = split.Run
+		}
+		if split.RemainOnExit != nil {
+			(*final).RemainOnExit = split.RemainOnExit
 		}
 		if split.Vertical != nil {
 			(*final).Vertical = split.Vertical
@@ -100,6 +225,16 @@ func mergeSplits(target, override []Split) []Split {
 		if split.Directory != nil {
 			(*final).Directory = split.Directory
 		}
+		if split.Name != nil {
+			(*final).Name = split.Name
+		}
+		if split.Target != nil {
+			(*final).Target = split.Target
+		}
+
+		(*final).Env = MergeEnv(final.Env, split.Env)
+
+		(*final).Splits = mergeSplits(final.Splits, split.Splits)
 	}
 
 	return append(merged, extras...)
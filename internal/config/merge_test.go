@@ -12,18 +12,49 @@ var sessionMergeCases = []struct {
 	override Session
 	final    Session
 }{
-	// NB: the last two fields are not merged (Debug, Logger)
 	{
 		"no-override",
-		Session{"./", "test-session", t_ptr(true), t_ptr("./.automux"), nil, false, nil},
+		Session{Directory: "./", SessionId: "test-session", AttachExisting: t_ptr(true), ConfigPath: t_ptr("./.automux")},
 		Session{},
-		Session{"./", "test-session", t_ptr(true), t_ptr("./.automux"), nil, false, nil},
+		Session{Directory: "./", SessionId: "test-session", AttachExisting: t_ptr(true), ConfigPath: t_ptr("./.automux")},
 	},
 	{
 		"full-override",
-		Session{"./", "test-session", t_ptr(true), t_ptr("./.automux"), nil, false, nil},
-		Session{"../", "better-session", t_ptr(false), t_ptr("../.automux"), []Window{{}}, false, nil},
-		Session{"../", "better-session", t_ptr(false), t_ptr("../.automux"), []Window{{}}, false, nil},
+		Session{Directory: "./", SessionId: "test-session", AttachExisting: t_ptr(true), ConfigPath: t_ptr("./.automux")},
+		Session{
+			Directory:      "../",
+			SessionId:      "better-session",
+			AttachExisting: t_ptr(false),
+			ConfigPath:     t_ptr("../.automux"),
+			SocketName:     "work",
+			Windows:        []Window{{}},
+		},
+		Session{
+			Directory:      "../",
+			SessionId:      "better-session",
+			AttachExisting: t_ptr(false),
+			ConfigPath:     t_ptr("../.automux"),
+			SocketName:     "work",
+			Windows:        []Window{{}},
+		},
+	},
+	{
+		"socket-override",
+		Session{SessionId: "test-session", SocketName: "work"},
+		Session{SocketPath: "/tmp/tmux.sock"},
+		Session{SessionId: "test-session", SocketPath: "/tmp/tmux.sock"},
+	},
+	{
+		"hooks-override",
+		Session{Hooks: &Hooks{BeforeCreate: StringList{"make deps"}, OnKill: StringList{"docker compose stop"}}},
+		Session{Hooks: &Hooks{OnKill: StringList{"docker compose down"}}},
+		Session{Hooks: &Hooks{BeforeCreate: StringList{"make deps"}, OnKill: StringList{"docker compose down"}}},
+	},
+	{
+		"env-file-override",
+		Session{EnvFile: StringList{".env"}},
+		Session{EnvFile: StringList{".env.test"}},
+		Session{EnvFile: StringList{".env.test"}},
 	},
 }
 
@@ -44,25 +75,49 @@ var windowMergeCases = []struct {
 }{
 	{
 		"no-override",
-		[]Window{{"win-1", t_ptr("nvim"), t_ptr(true), nil, nil}},
+		[]Window{{Title: "win-1", Exec: StringList{"nvim"}, Focus: t_ptr(true)}},
 		[]Window{{Title: "win-1"}},
-		[]Window{{"win-1", t_ptr("nvim"), t_ptr(true), nil, nil}},
+		[]Window{{Title: "win-1", Exec: StringList{"nvim"}, Focus: t_ptr(true)}},
 	},
 	{
 		"no-override",
-		[]Window{{"win-2", t_ptr("nvim"), t_ptr(true), nil, nil}},
-		[]Window{{"win-2", t_ptr("vim"), t_ptr(false), t_ptr("sub/"), []Split{{}}}},
-		[]Window{{"win-2", t_ptr("vim"), t_ptr(false), t_ptr("sub/"), []Split{{}}}},
+		[]Window{{Title: "win-2", Exec: StringList{"nvim"}, Focus: t_ptr(true)}},
+		[]Window{{Title: "win-2", Exec: StringList{"vim"}, Focus: t_ptr(false), Directory: t_ptr("sub/"), Splits: []Split{{}}}},
+		[]Window{{Title: "win-2", Exec: StringList{"vim"}, Focus: t_ptr(false), Directory: t_ptr("sub/"), Splits: []Split{{}}}},
 	},
 	{
 		"multi-windows",
-		[]Window{{"win-1", t_ptr("nvim"), t_ptr(true), nil, nil}},
-		[]Window{{Title: "win-1"}, {"win-2", t_ptr("vim"), t_ptr(false), nil, nil}},
+		[]Window{{Title: "win-1", Exec: StringList{"nvim"}, Focus: t_ptr(true)}},
+		[]Window{{Title: "win-1"}, {Title: "win-2", Exec: StringList{"vim"}, Focus: t_ptr(false)}},
 		[]Window{
-			{"win-1", t_ptr("nvim"), t_ptr(true), nil, nil},
-			{"win-2", t_ptr("vim"), t_ptr(false), nil, nil},
+			{Title: "win-1", Exec: StringList{"nvim"}, Focus: t_ptr(true)},
+			{Title: "win-2", Exec: StringList{"vim"}, Focus: t_ptr(false)},
 		},
 	},
+	{
+		"layout-override",
+		[]Window{{Title: "win-1", Layout: t_ptr("tiled"), Splits: []Split{{}}}},
+		[]Window{{Title: "win-1", Layout: t_ptr("main-vertical")}},
+		[]Window{{Title: "win-1", Layout: t_ptr("main-vertical"), Splits: []Split{{}}}},
+	},
+	{
+		"exec-replace",
+		[]Window{{Title: "win-1", Exec: StringList{"cd api", "make run"}}},
+		[]Window{{Title: "win-1", Exec: StringList{"make test"}, ExecDelay: t_ptr(500)}},
+		[]Window{{Title: "win-1", Exec: StringList{"make test"}, ExecDelay: t_ptr(500)}},
+	},
+	{
+		"exec-append",
+		[]Window{{Title: "win-1", Exec: StringList{"cd api"}}},
+		[]Window{{Title: "win-1", Exec: StringList{"make run"}, ExecAppend: t_ptr(true)}},
+		[]Window{{Title: "win-1", Exec: StringList{"cd api", "make run"}}},
+	},
+	{
+		"env",
+		[]Window{{Title: "win-1", Env: map[string]string{"PORT": "8080", "DEBUG": "1"}}},
+		[]Window{{Title: "win-1", Env: map[string]string{"PORT": "9090"}}},
+		[]Window{{Title: "win-1", Env: map[string]string{"PORT": "9090", "DEBUG": "1"}}},
+	},
 }
 
 func TestMergeWindows(t *testing.T) {
@@ -82,45 +137,81 @@ var splitMergeCases = []struct {
 }{
 	{
 		"no-override",
-		[]Split{{t_ptr(true), t_ptr("nvim"), t_ptr(10), t_ptr(false), nil}},
+		[]Split{{Vertical: t_ptr(true), Exec: StringList{"nvim"}, Size: t_ptr(10), Focus: t_ptr(false)}},
 		[]Split{{}},
-		[]Split{{t_ptr(true), t_ptr("nvim"), t_ptr(10), t_ptr(false), nil}},
+		[]Split{{Vertical: t_ptr(true), Exec: StringList{"nvim"}, Size: t_ptr(10), Focus: t_ptr(false)}},
 	},
 	{
 		"full-override",
-		[]Split{{t_ptr(true), t_ptr("nvim"), t_ptr(10), t_ptr(false), nil}},
-		[]Split{{t_ptr(false), t_ptr("vim"), t_ptr(20), t_ptr(true), nil}},
-		[]Split{{t_ptr(false), t_ptr("vim"), t_ptr(20), t_ptr(true), nil}},
+		[]Split{{Vertical: t_ptr(true), Exec: StringList{"nvim"}, Size: t_ptr(10), Focus: t_ptr(false)}},
+		[]Split{{Vertical: t_ptr(false), Exec: StringList{"vim"}, Size: t_ptr(20), Focus: t_ptr(true)}},
+		[]Split{{Vertical: t_ptr(false), Exec: StringList{"vim"}, Size: t_ptr(20), Focus: t_ptr(true)}},
 	},
 	{
 		"multi-splits",
 		[]Split{
-			{t_ptr(true), t_ptr("nvim"), t_ptr(10), t_ptr(false), nil},
-			{t_ptr(true), t_ptr("nvim"), t_ptr(10), t_ptr(false), nil},
+			{Vertical: t_ptr(true), Exec: StringList{"nvim"}, Size: t_ptr(10), Focus: t_ptr(false)},
+			{Vertical: t_ptr(true), Exec: StringList{"nvim"}, Size: t_ptr(10), Focus: t_ptr(false)},
 		},
 		[]Split{
-			{t_ptr(false), t_ptr("vim"), t_ptr(20), t_ptr(true), nil},
+			{Vertical: t_ptr(false), Exec: StringList{"vim"}, Size: t_ptr(20), Focus: t_ptr(true)},
 			{},
 		},
 		[]Split{
-			{t_ptr(false), t_ptr("vim"), t_ptr(20), t_ptr(true), nil},
-			{t_ptr(true), t_ptr("nvim"), t_ptr(10), t_ptr(false), nil},
+			{Vertical: t_ptr(false), Exec: StringList{"vim"}, Size: t_ptr(20), Focus: t_ptr(true)},
+			{Vertical: t_ptr(true), Exec: StringList{"nvim"}, Size: t_ptr(10), Focus: t_ptr(false)},
 		},
 	},
 	{
 		"extra-splits",
 		[]Split{
-			{t_ptr(true), t_ptr("nvim"), t_ptr(10), t_ptr(false), t_ptr("sub/")},
+			{Vertical: t_ptr(true), Exec: StringList{"nvim"}, Size: t_ptr(10), Focus: t_ptr(false), Directory: t_ptr("sub/")},
 		},
 		[]Split{
 			{},
-			{t_ptr(true), t_ptr("vim"), t_ptr(15), t_ptr(false), nil},
+			{Vertical: t_ptr(true), Exec: StringList{"vim"}, Size: t_ptr(15), Focus: t_ptr(false)},
 		},
 		[]Split{
-			{t_ptr(true), t_ptr("nvim"), t_ptr(10), t_ptr(false), t_ptr("sub/")},
-			{t_ptr(true), t_ptr("vim"), t_ptr(15), t_ptr(false), nil},
+			{Vertical: t_ptr(true), Exec: StringList{"nvim"}, Size: t_ptr(10), Focus: t_ptr(false), Directory: t_ptr("sub/")},
+			{Vertical: t_ptr(true), Exec: StringList{"vim"}, Size: t_ptr(15), Focus: t_ptr(false)},
 		},
 	},
+	{
+		"nested-splits",
+		[]Split{
+			{Name: t_ptr("right"), Splits: []Split{{Exec: StringList{"htop"}}}},
+		},
+		[]Split{
+			{Splits: []Split{{Exec: StringList{"btop"}}, {Target: t_ptr("right")}}},
+		},
+		[]Split{
+			{Name: t_ptr("right"), Splits: []Split{{Exec: StringList{"btop"}}, {Target: t_ptr("right")}}},
+		},
+	},
+	{
+		"wait-for-override",
+		[]Split{{WaitFor: &WaitFor{Port: t_ptr("5432"), Timeout: t_ptr(10)}}},
+		[]Split{{WaitFor: &WaitFor{File: t_ptr("tmp/ready")}}},
+		[]Split{{WaitFor: &WaitFor{File: t_ptr("tmp/ready")}}},
+	},
+	{
+		"run",
+		[]Split{{Exec: StringList{"make run"}}},
+		[]Split{{Run: t_ptr("make run"), RemainOnExit: t_ptr(true), Exec: StringList{}}},
+		[]Split{{Run: t_ptr("make run"), RemainOnExit: t_ptr(true), Exec: StringList{}}},
+	},
+	{
+		"exec-append",
+		[]Split{{Exec: StringList{"source venv/bin/activate"}}},
+		[]Split{{Exec: StringList{"make run"}, ExecAppend: t_ptr(true)}},
+		[]Split{{Exec: StringList{"source venv/bin/activate", "make run"}}},
+	},
+	{
+		"env",
+		[]Split{{Env: map[string]string{"PORT": "8080", "DEBUG": "1"}}},
+		[]Split{{Env: map[string]string{"PORT": "9090"}}},
+		[]Split{{Env: map[string]string{"PORT": "9090", "DEBUG": "1"}}},
+	},
 }
 
 func TestMergeSplits(t *testing.T) {
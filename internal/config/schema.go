@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// SchemaURL is where the JSON Schema for the json and yaml config formats is published
+const SchemaURL = "https://raw.githubusercontent.com/indeedhat/automux/master/configs/automux.schema.json"
+
+// schemaKey is the field json configs use to point editors at the schema, it is not decoded
+const schemaKey = "$schema"
+
+var stringListType = reflect.TypeOf(StringList{})
+
+// schemaConstraints holds the rules for fields that accept less than their type allows, keyed by
+// the struct name and json field name
+var schemaConstraints = map[string]map[string]any{
+	"Config.version": {"const": 1},
+	"Split.size":     {"minimum": 0, "maximum": 99},
+}
+
+// schemaRequired holds the fields that must be set for each struct
+var schemaRequired = map[string][]string{
+	"Config":  {"version"},
+	"Window":  {"title"},
+	"Session": {"dir"},
+}
+
+// Schema generates the JSON Schema for the json and yaml config formats from the Config struct
+//
+// Include and global configs share the same format so session_id is not required by the schema
+func Schema() ([]byte, error) {
+	s := schemaBuilder{definitions: map[string]any{
+		"stringList": map[string]any{
+			"oneOf": []any{
+				map[string]any{"type": "string"},
+				map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+		},
+	}}
+
+	root := s.object(reflect.TypeOf(Config{}))
+	root["$schema"] = "http://json-schema.org/draft-07/schema#"
+	root["$id"] = SchemaURL
+	root["title"] = "automux config"
+	root["definitions"] = s.definitions
+	root["properties"].(map[string]any)[schemaKey] = map[string]any{"type": "string"}
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// schemaBuilder collects the definitions of each struct referenced by the config
+type schemaBuilder struct {
+	definitions map[string]any
+}
+
+// object builds the schema for a struct from the json names of its fields
+func (s schemaBuilder) object(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "" || name == "-" || !sf.IsExported() {
+			continue
+		}
+
+		property := s.property(sf.Type)
+		for key, value := range schemaConstraints[t.Name()+"."+name] {
+			property[key] = value
+		}
+
+		properties[name] = property
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+
+	if required, ok := schemaRequired[t.Name()]; ok {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// property builds the schema for a single field type
+func (s schemaBuilder) property(t reflect.Type) map[string]any {
+	if t == stringListType {
+		return map[string]any{"$ref": "#/definitions/stringList"}
+	}
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int:
+		return map[string]any{"type": "integer"}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.property(t.Elem())}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": s.property(t.Elem())}
+	case reflect.Struct:
+		name := strings.ToLower(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := s.definitions[name]; !ok {
+			// the entry is reserved before building so that recursive types such as Split terminate
+			s.definitions[name] = nil
+			s.definitions[name] = s.object(t)
+		}
+
+		return map[string]any{"$ref": "#/definitions/" + name}
+	}
+
+	return map[string]any{}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSchemaInSync checks that the published schema matches the config structs
+//
+// regenerate it with: go run . schema > configs/automux.schema.json
+func TestSchemaInSync(t *testing.T) {
+	data, err := Schema()
+	require.Nil(t, err)
+
+	published, err := os.ReadFile("../../configs/automux.schema.json")
+	require.Nil(t, err)
+	require.Equal(t, string(published), string(data))
+}
+
+// TestSchemaConstraints checks that the constrained and required fields exist in the schema
+func TestSchemaConstraints(t *testing.T) {
+	data, err := Schema()
+	require.Nil(t, err)
+
+	var schema struct {
+		Properties  map[string]any `json:"properties"`
+		Definitions map[string]struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"definitions"`
+	}
+	require.Nil(t, json.Unmarshal(data, &schema))
+
+	properties := func(name string) map[string]any {
+		if name == "Config" {
+			return schema.Properties
+		}
+
+		return schema.Definitions[strings.ToLower(name[:1])+name[1:]].Properties
+	}
+
+	for key := range schemaConstraints {
+		name, field, _ := strings.Cut(key, ".")
+		require.Contains(t, properties(name), field, key)
+	}
+
+	for name, fields := range schemaRequired {
+		for _, field := range fields {
+			require.Contains(t, properties(name), field, name)
+		}
+	}
+}
+
+// TestSchemaKey checks that the $schema field of a json config is not reported as unknown
+func TestSchemaKey(t *testing.T) {
+	dir := t.TempDir()
+	t_writeConfigs(t, dir, map[string]string{
+		JsonPath: `{"$schema": "` + SchemaURL + `", "version": 1, "session_id": "api"}`,
+	})
+
+	_, problems, err := Validate(filepath.Join(dir, JsonPath), nil, false)
+	require.Nil(t, err)
+	require.Empty(t, problems)
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Problem describes a single issue found with a config file
+type Problem struct {
+	File string
+	// Position is left empty when the problem cannot be tied to a specific line
+	Position
+	// Field is the path of the offending field, e.g. `window "vim" split 2 size`
+	Field   string
+	Message string
+	// Warning is set for problems that do not stop the config from being used
+	Warning bool
+
+	err error
+}
+
+// String formats the problem as file:line:column: severity: field: message
+func (p Problem) String() string {
+	var b strings.Builder
+	b.WriteString(p.File)
+
+	if p.Line > 0 {
+		fmt.Fprintf(&b, ":%d", p.Line)
+		if p.Column > 0 {
+			fmt.Fprintf(&b, ":%d", p.Column)
+		}
+	}
+
+	if p.Warning {
+		b.WriteString(": warning")
+	} else {
+		b.WriteString(": error")
+	}
+
+	if p.Field != "" {
+		fmt.Fprintf(&b, ": %s", p.Field)
+	}
+
+	fmt.Fprintf(&b, ": %s", p.Message)
+
+	return b.String()
+}
+
+// ValidationError is returned when a config has one or more problems that stop it from being used
+//
+// Problems contains every problem that was found including the warnings
+type ValidationError struct {
+	Problems []Problem
+}
+
+// Error implements error
+func (e *ValidationError) Error() string {
+	var lines []string
+	for _, problem := range e.Problems {
+		if !problem.Warning {
+			lines = append(lines, problem.String())
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the errors the problems were created from so they can be checked with errors.As
+func (e *ValidationError) Unwrap() []error {
+	var errs []error
+	for _, problem := range e.Problems {
+		if problem.err != nil {
+			errs = append(errs, problem.err)
+		}
+	}
+
+	return errs
+}
+
+// hasErrors checks if any of the problems are errors rather than warnings
+func hasErrors(problems []Problem) bool {
+	for _, problem := range problems {
+		if !problem.Warning {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validator checks the values of a loaded config
+type validator struct {
+	// docs holds the documents the config was loaded from in order of presedence, they are used to
+	// find the position of each problem
+	docs     []*document
+	problems []Problem
+}
+
+// newValidator creates a validator that starts with the problems found while indexing the docs
+func newValidator(docs []*document) *validator {
+	v := &validator{docs: docs}
+	for _, doc := range docs {
+		v.problems = append(v.problems, doc.problems...)
+	}
+
+	return v
+}
+
+// report adds a problem for the field, it is placed in the first document that defines the field
+// or the closest parent of it
+func (v *validator) report(field string, warning bool, err error) {
+	problem := Problem{Field: field, Message: err.Error(), Warning: warning, err: err}
+	if len(v.docs) > 0 {
+		problem.File = v.docs[0].file
+	}
+
+	for prefix := field; prefix != ""; {
+		for _, doc := range v.docs {
+			if pos, ok := doc.positions[prefix]; ok {
+				problem.File = doc.file
+				problem.Position = pos
+				v.problems = append(v.problems, problem)
+				return
+			}
+		}
+
+		i := strings.LastIndexByte(prefix, ' ')
+		if i < 0 {
+			break
+		}
+
+		prefix = prefix[:i]
+	}
+
+	v.problems = append(v.problems, problem)
+}
+
+// errorf reports an error level problem for the field
+func (v *validator) errorf(field, format string, args ...any) {
+	v.report(field, false, fmt.Errorf(format, args...))
+}
+
+// warnf reports a warning for the field
+func (v *validator) warnf(field, format string, args ...any) {
+	v.report(field, true, fmt.Errorf(format, args...))
+}
+
+// varError reports an undefined variable against the field it was referenced in
+func (v *validator) varError(err error) bool {
+	var varErr *VarError
+	if !errors.As(err, &varErr) {
+		return false
+	}
+
+	v.report(varErr.Field, false, varErr)
+	v.problems[len(v.problems)-1].Message = fmt.Sprintf("undefined variable %q", varErr.Name)
+
+	return true
+}
+
+// config checks the windows of the config along with each of its session blocks
+//
+// rawDirs holds the session directories as they were written in the config so that problems
+// use the same paths as the documents
+func (v *validator) config(c *Config, blocks []Session, rawDirs []string) {
+	v.windows("", c.Directory, c.Windows)
+
+	for i, session := range c.Sessions {
+		prefix := fmt.Sprintf("session %q", rawDirs[i])
+
+		if !dirExists("", session.Directory) {
+			v.warnf(prefix, "directory %s does not exist", session.Directory)
+			continue
+		}
+
+		// the windows from the sessions own config are checked when it is loaded
+		v.windows(prefix+" ", session.Directory, blocks[i].Windows)
+	}
+}
+
+// windows checks the windows of a single session
+func (v *validator) windows(prefix, dir string, windows []Window) {
+	var (
+		panes   = make(map[string]bool)
+		focused string
+	)
+
+	for _, window := range windows {
+		panes[window.Title] = true
+		collectPaneNames(window.Splits, panes)
+	}
+
+	for _, window := range windows {
+		field := fmt.Sprintf("%swindow %q", prefix, window.Title)
+
+		if window.Directory != nil && *window.Directory != "" && !dirExists(dir, *window.Directory) {
+			v.warnf(field+" dir", "directory %s does not exist", *window.Directory)
+		}
+
+		if window.Focus != nil && *window.Focus {
+			v.focus(field, &focused)
+		}
+
+		if err := window.ValidateLayout(); err != nil {
+			v.warnf(field+" layout", "%s", err)
+		}
+
+		v.waitFor(field, window.WaitFor, panes)
+
+		names := map[string]string{window.Title: field}
+		v.splits(field+" split ", dir, window, window.Splits, panes, names, &focused)
+	}
+}
+
+// splits checks each split and any splits nested within it
+func (v *validator) splits(
+	prefix, dir string,
+	window Window,
+	splits []Split,
+	panes map[string]bool,
+	names map[string]string,
+	focused *string,
+) {
+	for i, split := range splits {
+		field := fmt.Sprintf("%s%d", prefix, i+1)
+
+		// a size of 0 is the same as not setting one, the split takes half of the pane
+		if split.Size != nil && (*split.Size < 0 || *split.Size > 99) {
+			v.errorf(field+" size", "size must be between 0 and 99 (0 means unset), got %d", *split.Size)
+		}
+
+		if split.Directory != nil && *split.Directory != "" {
+			if splitDir := window.SplitDirectory(split); !dirExists(dir, splitDir) {
+				v.warnf(field+" dir", "directory %s does not exist", splitDir)
+			}
+		}
+
+		if split.Focus != nil && *split.Focus {
+			v.focus(field, focused)
+		}
+
+		if split.Name != nil && *split.Name != "" {
+			if other, ok := names[*split.Name]; ok {
+				v.warnf(field+" name", "name %q is already used by %s", *split.Name, other)
+			} else {
+				names[*split.Name] = field
+			}
+		}
+
+		if split.Target != nil && *split.Target != "" {
+			if _, ok := names[*split.Target]; !ok {
+				v.warnf(field+" target", "target %q does not name a split opened before it or the window", *split.Target)
+			}
+		}
+
+		v.waitFor(field, split.WaitFor, panes)
+		v.splits(field+".", dir, window, split.Splits, panes, names, focused)
+	}
+}
+
+// focus reports every focused pane after the first as only the last one will end up focused
+func (v *validator) focus(field string, focused *string) {
+	if *focused != "" {
+		v.warnf(field+" focus", "focus is also set on %s, only the last focused pane is used", *focused)
+	}
+
+	*focused = field
+}
+
+// waitFor checks that an output condition has a valid pattern and a pane to check
+func (v *validator) waitFor(field string, wait *WaitFor, panes map[string]bool) {
+	if wait == nil || wait.Output == nil || *wait.Output == "" {
+		return
+	}
+
+	field += " wait_for"
+
+	if _, err := regexp.Compile("(?m)" + *wait.Output); err != nil {
+		v.errorf(field+" output", "invalid pattern: %s", err)
+	}
+
+	if wait.Pane == nil || *wait.Pane == "" {
+		v.errorf(field+" output", "wait_for output requires a pane")
+	} else if !panes[*wait.Pane] {
+		v.warnf(field+" pane", "pane %q does not name a split or window in the session", *wait.Pane)
+	}
+}
+
+// collectPaneNames adds the name of every split at any depth to the set
+func collectPaneNames(splits []Split, names map[string]bool) {
+	for _, split := range splits {
+		if split.Name != nil && *split.Name != "" {
+			names[*split.Name] = true
+		}
+
+		collectPaneNames(split.Splits, names)
+	}
+}
+
+// dirExists checks if the directory exists, relative paths are resolved from base
+func dirExists(base, dir string) bool {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+
+	stat, err := os.Stat(dir)
+	return err == nil && stat.IsDir()
+}
@@ -0,0 +1,227 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var validateChecks = []struct {
+	name     string
+	path     string
+	data     string
+	expected []string
+}{
+	{
+		"icl",
+		DefaultPath,
+		`version = 1
+# a comment doesn't throw the lines off
+session_id = "api"
+colour = "red"
+
+window "editor" {
+    focus = true
+    dir = "missing"
+
+    split {
+        size = 120
+        focus = true
+    }
+}
+`,
+		[]string{
+			`4:1: warning: colour: unknown field "colour"`,
+			`8:5: warning: window "editor" dir: directory missing does not exist`,
+			`11:9: error: window "editor" split 1 size: size must be between 0 and 99 (0 means unset), got 120`,
+			`12:9: warning: window "editor" split 1 focus: focus is also set on window "editor", only the last focused pane is used`,
+		},
+	},
+	{
+		"json",
+		JsonPath,
+		`{
+  "version": 1,
+  "windows": [
+    {
+      "title": "server",
+      "wait_for": {"output": "ready"},
+      "splits": [{"size": 0}]
+    }
+  ]
+}
+`,
+		[]string{
+			`6:20: error: window "server" wait_for output: wait_for output requires a pane`,
+			`error: session_id: session_id is not set`,
+		},
+	},
+	{
+		"yaml",
+		YamlPath,
+		`version: 1
+session_id: api
+windows:
+  - title: editor
+    splits:
+      - name: logs
+      - name: logs
+        target: nope
+`,
+		[]string{
+			`7:9: warning: window "editor" split 2 name: name "logs" is already used by window "editor" split 1`,
+			`8:9: warning: window "editor" split 2 target: target "nope" does not name a split opened before it or the window`,
+		},
+	},
+	{
+		"icl-unclosed",
+		DefaultPath,
+		"version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {\n    exec = \"nvim\n}\n",
+		[]string{
+			`4:17: error: { is never closed with }`,
+			`5:5: error: exec: expected a value`,
+			`5:12: error: unterminated string`,
+		},
+	},
+	{
+		"json-syntax",
+		JsonPath,
+		"{\n  \"version\": 1,\n  \"session_id\": }\n",
+		[]string{
+			`3:17: error: invalid character '}' looking for beginning of value`,
+		},
+	},
+	{
+		"yaml-type",
+		YamlPath,
+		"version: 1\nsession_id: api\nwindows:\n  - title: editor\n    splits:\n      - size: big\n",
+		[]string{
+			"6: error: cannot unmarshal !!str `big` into int",
+		},
+	},
+	{
+		"undefined-var",
+		DefaultPath,
+		"version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {\n    dir = \"${root}\"\n}\n",
+		[]string{
+			`5:5: error: window "editor" dir: undefined variable "root"`,
+		},
+	},
+	{
+		"icl-colon-block",
+		DefaultPath,
+		"version = 1\nsession_id = \"api\"\nvars { port: \"8080\" }\n",
+		[]string{
+			"3:1: error: expected `name = value`",
+			`3:14: error: unexpected "8080"`,
+		},
+	},
+	{
+		"icl-colon-unknown-block",
+		DefaultPath,
+		"version = 1\nsession_id = \"api\"\nfoo { bar: 1 }\n",
+		[]string{
+			"3:1: error: expected `name = value`",
+			`3:12: error: unexpected 1`,
+		},
+	},
+	{
+		"icl-broken-list",
+		DefaultPath,
+		"version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {\n    exec = [1, }\n}\n",
+		[]string{
+			`5:5: error: exec: expected a list value`,
+			`5:12: error: [ is never closed with ]`,
+			`6:1: error: unexpected }`,
+		},
+	},
+}
+
+// TestValidate checks that each problem is reported with its position and field
+func TestValidate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	for _, check := range validateChecks {
+		t.Run(check.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), check.path)
+			t_writeConfigs(t, filepath.Dir(path), map[string]string{check.path: check.data})
+
+			found, problems, err := Validate(filepath.Dir(path), nil, true)
+			require.Nil(t, err)
+			require.Equal(t, path, found)
+
+			var actual []string
+			for _, problem := range problems {
+				actual = append(actual, problem.String())
+			}
+
+			var expected []string
+			for _, problem := range check.expected {
+				if problem[0] >= '0' && problem[0] <= '9' {
+					expected = append(expected, path+":"+problem)
+				} else {
+					expected = append(expected, path+": "+problem)
+				}
+			}
+
+			require.Equal(t, expected, actual)
+		})
+	}
+}
+
+// TestValidateIncludes checks that problems are placed in the file that defines the field
+func TestValidateIncludes(t *testing.T) {
+	var (
+		dir = t.TempDir()
+		sub = filepath.Join(dir, "sub")
+	)
+
+	t_writeConfigs(t, dir, map[string]string{
+		DefaultPath:      "version = 1\nsession_id = \"api\"\ninclude = \"shared\"\nsession \"" + sub + "\" {}\n",
+		"shared.automux": "version = 1\n\nwindow \"editor\" {\n    split {\n        size = -5\n    }\n}\n",
+		"sub/.automux":   "version = 1\n\nwindow \"logs\" {\n    dir = \"nope\"\n}\n",
+	})
+
+	_, problems, err := Validate(dir, nil, false)
+	require.Nil(t, err)
+
+	var actual []string
+	for _, problem := range problems {
+		actual = append(actual, problem.String())
+	}
+
+	require.ElementsMatch(t, []string{
+		filepath.Join(sub, DefaultPath) + `:4:5: warning: window "logs" dir: directory nope does not exist`,
+		filepath.Join(dir, DefaultPath) + fmt.Sprintf(`:4:1: warning: session %q: the session has no session_id and will not be started`, sub),
+		filepath.Join(dir, "shared.automux") + `:5:9: error: window "editor" split 1 size: size must be between 0 and 99 (0 means unset), got -5`,
+	}, actual)
+}
+
+// TestLoadValidation checks that only errors stop a config from loading
+func TestLoadValidation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultPath)
+
+	t_writeConfigs(t, dir, map[string]string{
+		DefaultPath: "version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {\n    dir = \"missing\"\n}\n",
+	})
+
+	c, err := Load(path, false, nil, false)
+	require.Nil(t, err)
+	require.Equal(t, "api", c.SessionId)
+
+	t_writeConfigs(t, dir, map[string]string{
+		DefaultPath: "version = 1\nsession_id = \"api\"\n\nwindow \"editor\" {\n    split {\n        size = 100\n    }\n}\n",
+	})
+
+	_, err = Load(path, false, nil, false)
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr), "%v", err)
+	require.Len(t, validationErr.Problems, 1)
+	require.Equal(t, `window "editor" split 1 size`, validationErr.Problems[0].Field)
+	require.Equal(t, Position{Line: 6, Column: 9}, validationErr.Problems[0].Position)
+}
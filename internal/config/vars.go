@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// varPattern matches ${name} references along with the escaped $${name} form
+var varPattern = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+// VarError is returned when a config field references a variable that has not been defined
+type VarError struct {
+	// Field names the config field the reference was found in, e.g. `window "vim" split 2 exec`
+	Field string
+	Name  string
+}
+
+// Error implements error
+func (e *VarError) Error() string {
+	return fmt.Sprintf("%s: undefined variable %q", e.Field, e.Name)
+}
+
+// interpolator expands the variables referenced within config fields
+type interpolator struct {
+	vars map[string]string
+	// dir is the absolute directory of the config file being loaded
+	dir string
+}
+
+// newInterpolator resolves the configs vars block with any overrides taking presedence
+//
+// var values may reference ${env:NAME} and ${dir} but not other vars
+func newInterpolator(path string, vars, overrides map[string]string) (*interpolator, error) {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		builtin  = &interpolator{dir: dir}
+		resolved = make(map[string]string, len(vars)+len(overrides))
+	)
+
+	for key, value := range vars {
+		if resolved[key], err = builtin.expand("vars "+key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range overrides {
+		resolved[key] = value
+	}
+
+	return &interpolator{vars: resolved, dir: dir}, nil
+}
+
+// expand replaces each ${name}, ${env:NAME} and ${dir} reference in the value
+//
+// $${...} is left in place as a literal ${...} so that shell expansions can still be used
+func (i *interpolator) expand(field, value string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var err error
+	expanded := varPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		name := match[2 : len(match)-1]
+		resolved, ok := i.lookup(name)
+		if !ok && err == nil {
+			err = &VarError{Field: field, Name: name}
+		}
+
+		return resolved
+	})
+
+	return expanded, err
+}
+
+// lookup finds the value of a single variable reference
+func (i *interpolator) lookup(name string) (string, bool) {
+	if env, ok := strings.CutPrefix(name, "env:"); ok {
+		return os.LookupEnv(env)
+	}
+
+	if name == "dir" {
+		return i.dir, true
+	}
+
+	value, ok := i.vars[name]
+	return value, ok
+}
+
+// expandPtr expands the value of an optional field in place
+func (i *interpolator) expandPtr(field string, value *string) error {
+	if value == nil {
+		return nil
+	}
+
+	expanded, err := i.expand(field, *value)
+	if err != nil {
+		return err
+	}
+
+	*value = expanded
+	return nil
+}
+
+// expandList expands each entry of a list field in place
+func (i *interpolator) expandList(field string, list StringList) error {
+	for j := range list {
+		expanded, err := i.expand(field, list[j])
+		if err != nil {
+			return err
+		}
+
+		list[j] = expanded
+	}
+
+	return nil
+}
+
+// interpolate expands the variables in the configs session_id, config, dir, exec and run fields
+// along with those of its session blocks
+func (i *interpolator) interpolate(c *Config) error {
+	var err error
+	if c.SessionId, err = i.expand("session_id", c.SessionId); err != nil {
+		return err
+	}
+	if c.ConfigPath, err = i.expand("config", c.ConfigPath); err != nil {
+		return err
+	}
+
+	if err := i.windows("", c.Windows); err != nil {
+		return err
+	}
+
+	for j := range c.Sessions {
+		session := &c.Sessions[j]
+		prefix := fmt.Sprintf("session %q ", session.Directory)
+
+		if session.Directory, err = i.expand(prefix+"dir", session.Directory); err != nil {
+			return err
+		}
+		if session.SessionId, err = i.expand(prefix+"session_id", session.SessionId); err != nil {
+			return err
+		}
+		if err := i.expandPtr(prefix+"config", session.ConfigPath); err != nil {
+			return err
+		}
+		if err := i.windows(prefix, session.Windows); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// windows expands the variables within each window and its splits
+func (i *interpolator) windows(prefix string, windows []Window) error {
+	for j := range windows {
+		window := &windows[j]
+		field := fmt.Sprintf("%swindow %q ", prefix, window.Title)
+
+		if err := i.expandPtr(field+"dir", window.Directory); err != nil {
+			return err
+		}
+		if err := i.expandList(field+"exec", window.Exec); err != nil {
+			return err
+		}
+		if err := i.expandPtr(field+"run", window.Run); err != nil {
+			return err
+		}
+		if err := i.splits(field+"split ", window.Splits); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splits expands the variables within each split, nested splits are named by their position
+// within each level e.g. split 2.1
+func (i *interpolator) splits(prefix string, splits []Split) error {
+	for j := range splits {
+		split := &splits[j]
+		field := fmt.Sprintf("%s%d", prefix, j+1)
+
+		if err := i.expandPtr(field+" dir", split.Directory); err != nil {
+			return err
+		}
+		if err := i.expandList(field+" exec", split.Exec); err != nil {
+			return err
+		}
+		if err := i.expandPtr(field+" run", split.Run); err != nil {
+			return err
+		}
+		if err := i.splits(field+".", split.Splits); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
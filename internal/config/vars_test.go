@@ -0,0 +1,104 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var varsDocument = `
+version = 1
+session_id = "${name}-dev"
+vars = {name: "api", port: "8080", root: "${dir}/src"}
+
+window "Server" {
+    dir = "${root}"
+    exec = ["export PORT=${port}", "echo $${HOME}", "make run"]
+
+    split {
+        run = "curl localhost:${port}"
+        dir = "${env:AUTOMUX_VARS_TEST}"
+    }
+}
+`
+
+// TestLoadVars checks that variables are expanded across the configs fields
+func TestLoadVars(t *testing.T) {
+	t.Setenv("AUTOMUX_VARS_TEST", "/tmp")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultPath)
+	require.Nil(t, os.WriteFile(path, []byte(varsDocument), 0644))
+
+	c, err := Load(path, false, nil, false)
+	require.Nil(t, err)
+
+	window := c.Windows[0]
+	require.Equal(t, "api-dev", c.SessionId)
+	require.Equal(t, dir+"/src", *window.Directory)
+	require.Equal(t, StringList{"export PORT=8080", "echo ${HOME}", "make run"}, window.Exec)
+	require.Equal(t, "curl localhost:8080", *window.Splits[0].Run)
+	require.Equal(t, "/tmp", *window.Splits[0].Directory)
+
+	c, err = Load(path, false, map[string]string{"port": "9090", "root": "/srv"}, false)
+	require.Nil(t, err)
+
+	window = c.Windows[0]
+	require.Equal(t, "/srv", *window.Directory)
+	require.Equal(t, StringList{"export PORT=9090", "echo ${HOME}", "make run"}, window.Exec)
+}
+
+var varErrorChecks = []struct {
+	name  string
+	data  string
+	field string
+	ref   string
+}{
+	{
+		"session-id",
+		"version = 1\nsession_id = \"${name}\"\n",
+		"session_id",
+		"name",
+	},
+	{
+		"nested-split",
+		"version = 1\nwindow \"vim\" {\n    split {}\n    split {\n        split {\n            exec = \"${cmd}\"\n        }\n    }\n}\n",
+		`window "vim" split 2.1 exec`,
+		"cmd",
+	},
+	{
+		"env",
+		"version = 1\nwindow \"vim\" {\n    dir = \"${env:AUTOMUX_VARS_MISSING}\"\n}\n",
+		`window "vim" dir`,
+		"env:AUTOMUX_VARS_MISSING",
+	},
+	{
+		"var-references-var",
+		"version = 1\nvars = {a: \"x\", b: \"${a}\"}\n",
+		"vars b",
+		"a",
+	},
+}
+
+// TestLoadVarsUndefined checks that referencing an undefined variable fails to load and names the field
+func TestLoadVarsUndefined(t *testing.T) {
+	for _, check := range varErrorChecks {
+		t.Run(check.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultPath)
+			require.Nil(t, os.WriteFile(path, []byte(check.data), 0644))
+
+			_, err := Load(path, false, nil, false)
+
+			var varErr *VarError
+			require.True(t, errors.As(err, &varErr), "%v", err)
+			require.Equal(t, check.field, varErr.Field)
+			require.Equal(t, check.ref, varErr.Name)
+
+			_, err = LoadAny(filepath.Dir(path), false, nil, false)
+			require.True(t, errors.As(err, &varErr), "%v", err)
+		})
+	}
+}
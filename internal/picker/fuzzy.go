@@ -0,0 +1,117 @@
+package picker
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	scoreMatch       = 16
+	scoreConsecutive = 32
+	scoreBoundary    = 24
+	penaltyGap       = 1
+)
+
+// Match reports whether every rune of the query appears in the candidate in order (case insensitive)
+// along with a score for how good the match is, higher scores are better matches
+//
+// Consecutive runs of matching characters and matches at the start of a path segment or word
+// are favoured so that "mux" will rank "automux" above "my-useless-experiments"
+func Match(query, candidate string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+
+	var (
+		q     = []rune(strings.ToLower(query))
+		c     = []rune(strings.ToLower(candidate))
+		best  int
+		found bool
+	)
+
+	// the match is greedy from its starting point so try every start and keep the best
+	for start := range c {
+		if c[start] != q[0] {
+			continue
+		}
+
+		score, ok := matchFrom(q, c, start)
+		if !ok {
+			// if there is no match from here there will be none from any later start
+			break
+		}
+
+		if !found || score > best {
+			best = score
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// matchFrom greedily matches the query against the candidate starting at the given index
+func matchFrom(q, c []rune, start int) (int, bool) {
+	var (
+		score int
+		qi    int
+		last  = -1
+	)
+
+	for ci := start; ci < len(c) && qi < len(q); ci++ {
+		if c[ci] != q[qi] {
+			continue
+		}
+
+		score += scoreMatch
+
+		if last != -1 && last == ci-1 {
+			score += scoreConsecutive
+		} else if last != -1 {
+			score -= (ci - last - 1) * penaltyGap
+		}
+
+		if ci == 0 || isBoundary(c[ci-1]) {
+			score += scoreBoundary
+		}
+
+		last = ci
+		qi++
+	}
+
+	return score, qi == len(q)
+}
+
+// Filter returns the items that match the query ordered from best to worst match
+//
+// Items with the same score keep the order they were provided in
+func Filter(items []Item, query string) []Item {
+	type scored struct {
+		item  Item
+		score int
+	}
+
+	var matches []scored
+	for _, item := range items {
+		if score, ok := Match(query, item.Label); ok {
+			matches = append(matches, scored{item, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	filtered := make([]Item, 0, len(matches))
+	for _, m := range matches {
+		filtered = append(filtered, m.item)
+	}
+
+	return filtered
+}
+
+// isBoundary checks if the rune separates words or path segments
+func isBoundary(r rune) bool {
+	return r == '/' || r == '-' || r == '_' || r == '.' || unicode.IsSpace(r)
+}
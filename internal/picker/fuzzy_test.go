@@ -0,0 +1,57 @@
+package picker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var matchChecks = []struct {
+	name      string
+	query     string
+	candidate string
+	matches   bool
+}{
+	{"empty-query", "", "~/projects/automux", true},
+	{"exact", "automux", "automux", true},
+	{"subsequence", "amx", "~/projects/automux", true},
+	{"case-insensitive", "AUTO", "~/projects/automux", true},
+	{"out-of-order", "xa", "automux", false},
+	{"missing-rune", "automuz", "~/projects/automux", false},
+}
+
+func TestMatch(t *testing.T) {
+	for _, check := range matchChecks {
+		t.Run(check.name, func(t *testing.T) {
+			_, ok := Match(check.query, check.candidate)
+			require.Equal(t, check.matches, ok)
+		})
+	}
+}
+
+// TestMatchScore checks that tighter matches on word boundaries are ranked higher
+func TestMatchScore(t *testing.T) {
+	consecutive, _ := Match("mux", "~/projects/automux")
+	scattered, _ := Match("mux", "~/projects/my-useless-experiments")
+	require.Greater(t, consecutive, scattered)
+
+	boundary, _ := Match("am", "~/projects/api-manager")
+	inner, _ := Match("am", "~/projects/llama")
+	require.Greater(t, boundary, inner)
+}
+
+func TestFilter(t *testing.T) {
+	items := []Item{
+		{Label: "~/projects/icl"},
+		{Label: "~/projects/my-useless-experiments"},
+		{Label: "~/projects/automux"},
+	}
+
+	filtered := Filter(items, "mux")
+	require.Len(t, filtered, 2)
+	require.Equal(t, "~/projects/automux", filtered[0].Label)
+	require.Equal(t, "~/projects/my-useless-experiments", filtered[1].Label)
+
+	require.Equal(t, items, Filter(items, ""))
+	require.Empty(t, Filter(items, "zzz"))
+}
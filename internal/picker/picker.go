@@ -0,0 +1,197 @@
+package picker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// keyKind identifies the action a key press maps to
+type keyKind int
+
+const (
+	keyRune keyKind = iota
+	keyBackspace
+	keyClear
+	keyUp
+	keyDown
+	keyEnter
+	keyCancel
+	keyUnknown
+)
+
+// key is a single decoded key press
+type key struct {
+	kind keyKind
+	r    rune
+}
+
+// parseKeys decodes the raw bytes read from a terminal in raw mode into key presses
+func parseKeys(b []byte) []key {
+	var keys []key
+
+	for len(b) > 0 {
+		switch {
+		case b[0] == 0x1b && len(b) >= 3 && (b[1] == '[' || b[1] == 'O'):
+			switch b[2] {
+			case 'A':
+				keys = append(keys, key{kind: keyUp})
+			case 'B':
+				keys = append(keys, key{kind: keyDown})
+			default:
+				keys = append(keys, key{kind: keyUnknown})
+			}
+			b = b[3:]
+			continue
+		case b[0] == 0x1b, b[0] == 0x03, b[0] == 0x07:
+			// esc, ctrl-c, ctrl-g
+			keys = append(keys, key{kind: keyCancel})
+		case b[0] == '\r', b[0] == '\n':
+			keys = append(keys, key{kind: keyEnter})
+		case b[0] == 0x7f, b[0] == 0x08:
+			keys = append(keys, key{kind: keyBackspace})
+		case b[0] == 0x15:
+			// ctrl-u
+			keys = append(keys, key{kind: keyClear})
+		case b[0] == 0x10, b[0] == 0x0b:
+			// ctrl-p, ctrl-k
+			keys = append(keys, key{kind: keyUp})
+		case b[0] == 0x0e:
+			// ctrl-n
+			keys = append(keys, key{kind: keyDown})
+		case b[0] < 0x20:
+			keys = append(keys, key{kind: keyUnknown})
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, key{kind: keyRune, r: r})
+			b = b[size:]
+			continue
+		}
+
+		b = b[1:]
+	}
+
+	return keys
+}
+
+// Picker holds the state of the interactive fuzzy finder
+type Picker struct {
+	items   []Item
+	matches []Item
+	query   []rune
+	cursor  int
+	offset  int
+}
+
+// New creates a Picker for the given items
+func New(items []Item) *Picker {
+	return &Picker{items: items, matches: items}
+}
+
+// Selected returns the item currently under the cursor
+func (p *Picker) Selected() (Item, bool) {
+	if len(p.matches) == 0 {
+		return Item{}, false
+	}
+
+	return p.matches[p.cursor], true
+}
+
+// handle applies a key press to the picker state
+//
+// done will be set once the user has either made a choice or cancelled
+func (p *Picker) handle(k key) (done, cancelled bool) {
+	switch k.kind {
+	case keyRune:
+		if unicode.IsPrint(k.r) {
+			p.query = append(p.query, k.r)
+			p.filter()
+		}
+	case keyBackspace:
+		if len(p.query) > 0 {
+			p.query = p.query[:len(p.query)-1]
+			p.filter()
+		}
+	case keyClear:
+		p.query = nil
+		p.filter()
+	case keyUp:
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case keyDown:
+		if p.cursor < len(p.matches)-1 {
+			p.cursor++
+		}
+	case keyEnter:
+		_, ok := p.Selected()
+		return ok, false
+	case keyCancel:
+		return true, true
+	}
+
+	return false, false
+}
+
+// filter re runs the query against the items and resets the cursor
+func (p *Picker) filter() {
+	p.matches = Filter(p.items, string(p.query))
+	p.cursor = 0
+	p.offset = 0
+}
+
+// render draws the picker to w, using at most height lines and width columns
+//
+// The terminal is expected to be in raw mode so lines are terminated with \r\n
+func (p *Picker) render(w io.Writer, height, width int) {
+	var buf strings.Builder
+
+	// move to the top left and clear the screen
+	buf.WriteString("\x1b[H\x1b[2J")
+	buf.WriteString(truncate("> "+string(p.query), width) + "\r\n")
+	buf.WriteString(fmt.Sprintf("\x1b[2m  %d/%d\x1b[0m\r\n", len(p.matches), len(p.items)))
+
+	// leave the last line free so the trailing newline does not scroll the screen
+	rows := height - 3
+	if rows < 1 {
+		rows = 1
+	}
+
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	} else if p.cursor >= p.offset+rows {
+		p.offset = p.cursor - rows + 1
+	}
+
+	for i := p.offset; i < len(p.matches) && i < p.offset+rows; i++ {
+		item := p.matches[i]
+
+		marker := "  "
+		if item.Automux {
+			marker = "\x1b[32m●\x1b[0m "
+		}
+
+		label := truncate(item.Label, width-2)
+		if i == p.cursor {
+			label = "\x1b[7m" + label + "\x1b[0m"
+		}
+
+		buf.WriteString(marker + label + "\r\n")
+	}
+
+	// put the cursor back at the end of the query
+	buf.WriteString(fmt.Sprintf("\x1b[1;%dH", utf8.RuneCountInString(string(p.query))+3))
+
+	io.WriteString(w, buf.String())
+}
+
+// truncate shortens s to at most width runes
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+
+	return string([]rune(s)[:width])
+}
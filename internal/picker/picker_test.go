@@ -0,0 +1,79 @@
+package picker
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var pickerItems = []Item{
+	{Path: "/p/automux", Label: "~/p/automux", Automux: true},
+	{Path: "/p/icl", Label: "~/p/icl"},
+	{Path: "/p/tmux-sessionizer", Label: "~/p/tmux-sessionizer"},
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("a\x1b[A\x1b[B\r\x7f\x15\x03é"))
+
+	require.Equal(t, []key{
+		{kind: keyRune, r: 'a'},
+		{kind: keyUp},
+		{kind: keyDown},
+		{kind: keyEnter},
+		{kind: keyBackspace},
+		{kind: keyClear},
+		{kind: keyCancel},
+		{kind: keyRune, r: 'é'},
+	}, keys)
+}
+
+// TestPickerSelect types a query, moves the cursor and picks an item
+func TestPickerSelect(t *testing.T) {
+	p := New(pickerItems)
+
+	var done, cancelled bool
+	for _, k := range parseKeys([]byte("mux\x1b[B\r")) {
+		done, cancelled = p.handle(k)
+	}
+
+	require.True(t, done)
+	require.False(t, cancelled)
+
+	item, ok := p.Selected()
+	require.True(t, ok)
+	require.Equal(t, "/p/tmux-sessionizer", item.Path)
+}
+
+// TestPickerNoMatches checks that enter does nothing when the query matches nothing
+func TestPickerNoMatches(t *testing.T) {
+	p := New(pickerItems)
+
+	var done bool
+	for _, k := range parseKeys([]byte("zzz\r")) {
+		done, _ = p.handle(k)
+	}
+	require.False(t, done)
+
+	done, cancelled := p.handle(key{kind: keyCancel})
+	require.True(t, done)
+	require.True(t, cancelled)
+}
+
+func TestPickerRender(t *testing.T) {
+	p := New(pickerItems)
+	for _, k := range parseKeys([]byte("mux")) {
+		p.handle(k)
+	}
+
+	var b bytes.Buffer
+	p.render(&b, 24, 80)
+	out := b.String()
+
+	require.Contains(t, out, "> mux\r\n")
+	require.Contains(t, out, "2/3")
+	require.Contains(t, out, "●")
+	require.NotContains(t, out, "~/p/icl")
+	require.Less(t, strings.Index(out, "~/p/automux"), strings.Index(out, "~/p/tmux-sessionizer"))
+}
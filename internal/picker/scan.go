@@ -0,0 +1,135 @@
+package picker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/indeedhat/automux/internal/config"
+)
+
+// Item is a single directory that can be picked
+type Item struct {
+	// Path is the absolute path to the directory
+	Path string
+	// Label is the text displayed in the picker and matched against the query
+	Label string
+	// Automux is set when the directory contains an automux config
+	Automux bool
+}
+
+// ScanOptions controls which directories are collected by Scan
+type ScanOptions struct {
+	// Roots are the directories to search within, the roots themselves are not included
+	Roots []string
+	// Depth is the number of levels below each root that will be collected
+	Depth int
+	// Ignore contains glob patterns matched against each directory name, matching
+	// directories are skipped along with everything below them
+	Ignore []string
+}
+
+// Scan walks each of the root directories collecting every directory within the depth limit
+func Scan(opts ScanOptions) ([]Item, error) {
+	var (
+		items []Item
+		seen  = make(map[string]bool)
+		home  = homeDir()
+	)
+
+	if opts.Depth < 1 {
+		opts.Depth = 1
+	}
+
+	for _, root := range opts.Roots {
+		root, err := filepath.Abs(expandHome(root, home))
+		if err != nil {
+			return nil, err
+		}
+
+		if stat, err := os.Stat(root); err != nil || !stat.IsDir() {
+			return nil, fmt.Errorf("project root %s is not a directory", root)
+		}
+
+		var walk func(dir string, depth int)
+		walk = func(dir string, depth int) {
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				return
+			}
+
+			for _, entry := range entries {
+				if !entry.IsDir() || ignored(entry.Name(), opts.Ignore) {
+					continue
+				}
+
+				path := filepath.Join(dir, entry.Name())
+				if !seen[path] {
+					seen[path] = true
+					items = append(items, Item{
+						Path:    path,
+						Label:   shortenHome(path, home),
+						Automux: config.Exists(config.Paths(path)...),
+					})
+				}
+
+				if depth < opts.Depth {
+					walk(path, depth+1)
+				}
+			}
+		}
+
+		walk(root, 1)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+
+	return items, nil
+}
+
+// ignored checks the directory name against each of the ignore globs
+func ignored(name string, globs []string) bool {
+	for _, glob := range globs {
+		if ok, _ := filepath.Match(glob, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// homeDir returns the current users home directory or an empty string if it cannot be found
+func homeDir() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// expandHome replaces a leading ~ in the path with the users home directory
+func expandHome(path, home string) string {
+	if home == "" || (path != "~" && !strings.HasPrefix(path, "~/")) {
+		return path
+	}
+
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// shortenHome replaces the users home directory with ~ for display
+func shortenHome(path, home string) string {
+	if home == "" || home == "/" {
+		return path
+	}
+
+	if path == home {
+		return "~"
+	}
+
+	if strings.HasPrefix(path, home+string(filepath.Separator)) {
+		return "~" + strings.TrimPrefix(path, home)
+	}
+
+	return path
+}
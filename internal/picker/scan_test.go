@@ -0,0 +1,75 @@
+package picker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// t_projectTree builds a directory tree for scanning in a temp dir
+func t_projectTree(t *testing.T) string {
+	root := t.TempDir()
+
+	for _, dir := range []string{
+		"automux",
+		"icl",
+		"icl/sub",
+		"icl/sub/deeper",
+		"node_modules/pkg",
+		".git",
+	} {
+		require.Nil(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+
+	require.Nil(t, os.WriteFile(filepath.Join(root, "automux", ".automux.yml"), []byte("version: 1\n"), 0644))
+	require.Nil(t, os.WriteFile(filepath.Join(root, "not-a-dir"), nil, 0644))
+
+	return root
+}
+
+var scanChecks = []struct {
+	name     string
+	depth    int
+	ignore   []string
+	expected []string
+}{
+	{"depth-1", 1, nil, []string{".git", "automux", "icl", "node_modules"}},
+	{"depth-2", 2, []string{".git", "node_modules"}, []string{"automux", "icl", "icl/sub"}},
+	{"depth-3", 3, []string{".*", "node_*"}, []string{"automux", "icl", "icl/sub", "icl/sub/deeper"}},
+	{"ignore-parent", 3, []string{"icl", ".git", "node_modules"}, []string{"automux"}},
+}
+
+func TestScan(t *testing.T) {
+	root := t_projectTree(t)
+
+	for _, check := range scanChecks {
+		t.Run(check.name, func(t *testing.T) {
+			items, err := Scan(ScanOptions{Roots: []string{root}, Depth: check.depth, Ignore: check.ignore})
+			require.Nil(t, err)
+
+			var found []string
+			for _, item := range items {
+				rel, err := filepath.Rel(root, item.Path)
+				require.Nil(t, err)
+				found = append(found, rel)
+				require.Equal(t, rel == "automux", item.Automux, rel)
+			}
+
+			require.Equal(t, check.expected, found)
+		})
+	}
+}
+
+func TestScanBadRoot(t *testing.T) {
+	_, err := Scan(ScanOptions{Roots: []string{"../../_nope/exists"}})
+	require.NotNil(t, err)
+}
+
+func TestHomePaths(t *testing.T) {
+	require.Equal(t, "/home/user/projects", expandHome("~/projects", "/home/user"))
+	require.Equal(t, "/opt/~projects", expandHome("/opt/~projects", "/home/user"))
+	require.Equal(t, "~/projects", shortenHome("/home/user/projects", "/home/user"))
+	require.Equal(t, "/home/username", shortenHome("/home/username", "/home/user"))
+}
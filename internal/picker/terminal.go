@@ -0,0 +1,104 @@
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ErrCancelled is returned by Run when the user exits the picker without making a choice
+var ErrCancelled = errors.New("no selection made")
+
+// Run shows the picker on the controlling terminal and blocks until the user picks an item
+func Run(items []Item) (Item, error) {
+	if len(items) == 0 {
+		return Item{}, errors.New("no directories found to pick from")
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return Item{}, fmt.Errorf("picker needs an interactive terminal: %w", err)
+	}
+	defer tty.Close()
+
+	restore, err := rawMode(tty)
+	if err != nil {
+		return Item{}, err
+	}
+	defer restore()
+
+	// switch to the alternate screen so the picker does not trash the terminals scrollback
+	fmt.Fprint(tty, "\x1b[?1049h")
+	defer fmt.Fprint(tty, "\x1b[?1049l")
+
+	var (
+		p   = New(items)
+		buf = make([]byte, 64)
+	)
+
+	for {
+		height, width := size(tty)
+		p.render(tty, height, width)
+
+		n, err := tty.Read(buf)
+		if err != nil {
+			return Item{}, err
+		}
+
+		for _, k := range parseKeys(buf[:n]) {
+			done, cancelled := p.handle(k)
+			if cancelled {
+				return Item{}, ErrCancelled
+			}
+			if done {
+				item, _ := p.Selected()
+				return item, nil
+			}
+		}
+	}
+}
+
+// rawMode puts the terminal into raw mode returning a func to restore its original state
+//
+// stty is used rather than ioctls directly so that this works on any unix without a build per platform
+func rawMode(tty *os.File) (func(), error) {
+	state, err := stty(tty, "-g")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read terminal state: %w", err)
+	}
+
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+
+	return func() {
+		stty(tty, state)
+	}, nil
+}
+
+// size returns the height and width of the terminal falling back to 24x80 if it cannot be read
+func size(tty *os.File) (int, int) {
+	out, err := stty(tty, "size")
+	if err != nil {
+		return 24, 80
+	}
+
+	var height, width int
+	if _, err := fmt.Sscanf(out, "%d %d", &height, &width); err != nil || height == 0 {
+		return 24, 80
+	}
+
+	return height, width
+}
+
+// stty runs the stty command against the given terminal
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+
+	out, err := cmd.Output()
+
+	return strings.TrimSpace(string(out)), err
+}
@@ -0,0 +1,61 @@
+package tmux
+
+import (
+	"log"
+
+	"github.com/indeedhat/automux/internal/config"
+)
+
+// DryRunClient prints the tmux commands that would have been run rather than running them
+type DryRunClient struct {
+	l *log.Logger
+}
+
+// NewDryRunClient creates a Client that logs each command to the provided logger
+func NewDryRunClient(l *log.Logger) *DryRunClient {
+	return &DryRunClient{l: l}
+}
+
+// Run implements Client
+func (d *DryRunClient) Run(session config.Session, args ...string) error {
+	d.l.Println(newCommand(session, args...).String())
+	return nil
+}
+
+// Output implements Client
+//
+// Commands are printed the same as Run with no output returned
+func (d *DryRunClient) Output(session config.Session, args ...string) (string, error) {
+	return "", d.Run(session, args...)
+}
+
+// SessionExists implements Client
+//
+// In dry run mode no sessions ever exist so that the full build is always printed
+func (d *DryRunClient) SessionExists(session config.Session) bool {
+	return false
+}
+
+// AwaitSession implements Client
+func (d *DryRunClient) AwaitSession(session config.Session) error {
+	return nil
+}
+
+// Attach implements Client
+func (d *DryRunClient) Attach(session config.Session) error {
+	return d.Run(session, "attach", "-t", session.SessionId)
+}
+
+// Shell implements Client
+func (d *DryRunClient) Shell(session config.Session, command string) error {
+	d.l.Println("sh -c " + command)
+	return nil
+}
+
+// IsDryRun checks if the client is only printing commands rather than running them
+func IsDryRun(client Client) bool {
+	_, ok := client.(*DryRunClient)
+	return ok
+}
+
+var _ Client = (*DryRunClient)(nil)
@@ -0,0 +1,144 @@
+package tmux
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/indeedhat/automux/internal/config"
+)
+
+// ExecClient runs tmux commands against the real tmux binary
+type ExecClient struct{}
+
+// NewExecClient creates a Client that shells out to tmux
+func NewExecClient() *ExecClient {
+	return &ExecClient{}
+}
+
+// Run implements Client
+func (e *ExecClient) Run(session config.Session, args ...string) error {
+	c := newCommand(session, args...)
+	c.Dir = session.Directory
+
+	_, err := e.run(c)
+
+	return err
+}
+
+// Output implements Client
+//
+// tmux is run with -u so that the output is not sanitised for a non UTF-8 locale, without it tabs
+// in formats are replaced by underscores and non ascii characters are mangled
+func (e *ExecClient) Output(session config.Session, args ...string) (string, error) {
+	c := newCommand(session, args...)
+	c.Args = append([]string{"-u"}, c.Args...)
+	c.Dir = session.Directory
+
+	return e.run(c)
+}
+
+// SessionExists implements Client
+func (e *ExecClient) SessionExists(session config.Session) bool {
+	out, err := e.command(newCommand(session, "ls")).CombinedOutput()
+	if err != nil {
+		return false
+	}
+	s := bufio.NewScanner(bytes.NewReader(out))
+
+	for s.Scan() {
+		parts := strings.Split(s.Text(), ":")
+		if len(parts) > 0 && parts[0] == session.SessionId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AwaitSession implements Client
+func (e *ExecClient) AwaitSession(session config.Session) error {
+	ticker := time.NewTicker(2 * time.Millisecond)
+	defer ticker.Stop()
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case <-timeout:
+			return fmt.Errorf("%w: %s", ErrSessionTimeout, session.SessionId)
+		case <-ticker.C:
+			data, err := e.command(newCommand(session, "ls")).CombinedOutput()
+			if err != nil {
+				continue
+			}
+
+			s := bufio.NewScanner(bytes.NewReader(data))
+			for s.Scan() {
+				if strings.HasPrefix(s.Text(), session.SessionId) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// Attach implements Client
+func (e *ExecClient) Attach(session config.Session) error {
+	cmd := e.command(newCommand(session, "attach", "-t", session.SessionId))
+	cmd.Stdout = os.Stdout
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// Shell implements Client
+//
+// The output of the command is passed through to the terminal automux is running in
+func (e *ExecClient) Shell(session config.Session, command string) error {
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Dir = session.Directory
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	for key, value := range session.Env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", command, err)
+	}
+
+	return nil
+}
+
+// run executes the command capturing stdout and converting any failure into a *CommandError
+func (e *ExecClient) run(c Command) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := e.command(c)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", &CommandError{Command: c, Stderr: strings.TrimSpace(stderr.String()), Err: err}
+	}
+
+	return stdout.String(), nil
+}
+
+// command converts a Command into an *exec.Cmd ready to be run
+func (e *ExecClient) command(c Command) *exec.Cmd {
+	cmd := exec.Command("tmux", c.Args...)
+	if c.Dir != "" {
+		cmd.Dir = c.Dir
+	}
+
+	return cmd
+}
+
+var _ Client = (*ExecClient)(nil)
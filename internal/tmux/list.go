@@ -0,0 +1,244 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/indeedhat/automux/internal/config"
+)
+
+// SessionInfo describes a session running on a tmux server
+type SessionInfo struct {
+	Name     string
+	Attached bool
+	Windows  int
+	Path     string
+}
+
+// ListSessions returns every session running on the server targeted by the given session
+//
+// A server that is not running is treated as having no sessions rather than as an error
+func ListSessions(client Client, session config.Session) ([]SessionInfo, error) {
+	out, err := client.Output(
+		session,
+		"list-sessions",
+		"-F",
+		format("session_name", "session_attached", "session_windows", "session_path"),
+	)
+	if err != nil {
+		if noServer(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var sessions []SessionInfo
+	for _, fields := range parseRows(out, 4) {
+		windows, _ := strconv.Atoi(fields[2])
+		attached, _ := strconv.Atoi(fields[1])
+
+		sessions = append(sessions, SessionInfo{
+			Name:     fields[0],
+			Attached: attached > 0,
+			Windows:  windows,
+			Path:     fields[3],
+		})
+	}
+
+	return sessions, nil
+}
+
+// PaneInfo describes a single pane within a running session
+type PaneInfo struct {
+	WindowIndex int
+	WindowName  string
+	PaneIndex   int
+	Active      bool
+	// Command is the name of the process currently running in the foreground of the pane
+	Command string
+	Path    string
+	Width   int
+	Height  int
+	// Left and Top are the position of the pane within the window
+	Left int
+	Top  int
+}
+
+// ListPanes returns every pane in every window of the given session
+func ListPanes(client Client, session config.Session) ([]PaneInfo, error) {
+	out, err := client.Output(
+		session,
+		"list-panes",
+		"-s",
+		"-t",
+		session.SessionId,
+		"-F",
+		format(
+			"window_index",
+			"pane_index",
+			"pane_active",
+			"pane_width",
+			"pane_height",
+			"pane_left",
+			"pane_top",
+			"pane_current_command",
+			"pane_current_path",
+			"window_name",
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var panes []PaneInfo
+	for _, fields := range parseRows(out, 10) {
+		pane := PaneInfo{
+			Active:     fields[2] == "1",
+			Command:    fields[7],
+			Path:       fields[8],
+			WindowName: fields[9],
+		}
+		pane.WindowIndex, _ = strconv.Atoi(fields[0])
+		pane.PaneIndex, _ = strconv.Atoi(fields[1])
+		pane.Width, _ = strconv.Atoi(fields[3])
+		pane.Height, _ = strconv.Atoi(fields[4])
+		pane.Left, _ = strconv.Atoi(fields[5])
+		pane.Top, _ = strconv.Atoi(fields[6])
+
+		panes = append(panes, pane)
+	}
+
+	return panes, nil
+}
+
+// WindowInfo describes a single window within a running session
+type WindowInfo struct {
+	Index  int
+	Name   string
+	Active bool
+	Width  int
+	Height int
+}
+
+// ListWindows returns every window in the given session
+func ListWindows(client Client, session config.Session) ([]WindowInfo, error) {
+	out, err := client.Output(
+		session,
+		"list-windows",
+		"-t",
+		session.SessionId,
+		"-F",
+		format("window_index", "window_active", "window_width", "window_height", "window_name"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var windows []WindowInfo
+	for _, fields := range parseRows(out, 5) {
+		window := WindowInfo{
+			Active: fields[1] == "1",
+			Name:   fields[4],
+		}
+		window.Index, _ = strconv.Atoi(fields[0])
+		window.Width, _ = strconv.Atoi(fields[2])
+		window.Height, _ = strconv.Atoi(fields[3])
+
+		windows = append(windows, window)
+	}
+
+	return windows, nil
+}
+
+// CurrentSession returns the name of the session the tmux client running automux is attached to
+func CurrentSession(client Client, session config.Session) (string, error) {
+	out, err := client.Output(session, "display-message", "-p", format("session_name"))
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out), nil
+}
+
+// ActivePane returns the index of the active window within the session and the active pane within it
+func ActivePane(client Client, session config.Session) (int, int, error) {
+	out, err := client.Output(
+		session,
+		"display-message",
+		"-p",
+		"-t",
+		session.SessionId,
+		format("window_index", "pane_index"),
+	)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rows := parseRows(out, 2)
+	if len(rows) == 0 {
+		return 0, 0, fmt.Errorf("failed to read the active pane for %s from %q", session.SessionId, out)
+	}
+
+	window, _ := strconv.Atoi(rows[0][0])
+	pane, _ := strconv.Atoi(rows[0][1])
+
+	return window, pane, nil
+}
+
+// ListClients returns the name of every client attached to the session
+func ListClients(client Client, session config.Session) ([]string, error) {
+	out, err := client.Output(session, "list-clients", "-t", session.SessionId, "-F", format("client_name"))
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Fields(out), nil
+}
+
+// format builds a tmux format string that outputs each of the given variables separated by tabs
+//
+// The tabs are only kept intact because ExecClient.Output runs tmux with -u
+func format(vars ...string) string {
+	parts := make([]string, 0, len(vars))
+	for _, v := range vars {
+		parts = append(parts, "#{"+v+"}")
+	}
+
+	return strings.Join(parts, "\t")
+}
+
+// parseRows splits the output of a command run with a format from format into its fields
+//
+// rows with the wrong number of fields are skipped
+func parseRows(out string, fields int) [][]string {
+	var rows [][]string
+
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" {
+			continue
+		}
+
+		row := strings.SplitN(line, "\t", fields)
+		if len(row) != fields {
+			continue
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// noServer checks if the error was caused by there being no tmux server to talk to
+func noServer(err error) bool {
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+
+	return strings.Contains(cmdErr.Stderr, "no server running") ||
+		strings.Contains(cmdErr.Stderr, "error connecting to")
+}
@@ -0,0 +1,123 @@
+package tmux
+
+import (
+	"sync"
+
+	"github.com/indeedhat/automux/internal/config"
+)
+
+// Recorder is a fake Client that records every command it is given without touching tmux
+//
+// It is intended for use in tests that want to assert on the structured commands automux issues
+type Recorder struct {
+	mux sync.Mutex
+
+	// Commands contains every command that has been run, in order
+	Commands []Command
+	// Shells contains every shell command that has been run, in order
+	Shells []string
+	// Sessions contains the session ids that the recorder will report as existing
+	Sessions map[string]bool
+	// FailOn can be set to make the recorder return an error for specific commands
+	FailOn func(c Command) error
+	// Responses contains the output returned by Output keyed by the tmux command name
+	Responses map[string]string
+	// Respond can be set to generate the output returned by Output, it takes precedence over Responses
+	Respond func(c Command) string
+}
+
+// NewRecorder creates a Recorder that reports the given session ids as already running
+func NewRecorder(existing ...string) *Recorder {
+	r := &Recorder{Sessions: make(map[string]bool), Responses: make(map[string]string)}
+	for _, id := range existing {
+		r.Sessions[id] = true
+	}
+
+	return r
+}
+
+// Run implements Client
+func (r *Recorder) Run(session config.Session, args ...string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	c := newCommand(session, args...)
+	c.Dir = session.Directory
+	r.Commands = append(r.Commands, c)
+
+	if r.FailOn != nil {
+		if err := r.FailOn(c); err != nil {
+			return &CommandError{Command: c, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// Output implements Client
+func (r *Recorder) Output(session config.Session, args ...string) (string, error) {
+	if err := r.Run(session, args...); err != nil {
+		return "", err
+	}
+
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.Respond != nil {
+		return r.Respond(r.Commands[len(r.Commands)-1]), nil
+	}
+
+	return r.Responses[args[0]], nil
+}
+
+// SessionExists implements Client
+func (r *Recorder) SessionExists(session config.Session) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return r.Sessions[session.SessionId]
+}
+
+// AwaitSession implements Client
+func (r *Recorder) AwaitSession(session config.Session) error {
+	return nil
+}
+
+// Attach implements Client
+func (r *Recorder) Attach(session config.Session) error {
+	return r.Run(session, "attach", "-t", session.SessionId)
+}
+
+// Shell implements Client
+//
+// FailOn is given the shell command as sh -c <command>
+func (r *Recorder) Shell(session config.Session, command string) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.Shells = append(r.Shells, command)
+
+	if r.FailOn != nil {
+		c := Command{Args: []string{"sh", "-c", command}, Dir: session.Directory}
+		if err := r.FailOn(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Args returns the args of each recorded command, useful for comparing against expected output
+func (r *Recorder) Args() [][]string {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	args := make([][]string, 0, len(r.Commands))
+	for _, c := range r.Commands {
+		args = append(args, c.Args)
+	}
+
+	return args
+}
+
+var _ Client = (*Recorder)(nil)
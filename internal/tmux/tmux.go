@@ -1,80 +1,90 @@
 package tmux
 
 import (
-	"bufio"
-	"bytes"
-	"os/exec"
+	"errors"
+	"fmt"
 	"strings"
-	"time"
 
 	"github.com/indeedhat/automux/internal/config"
 )
 
-// Cmd is an alias function to make running subsequent tmux commands simpler and more readable
-func Cmd(session config.Session, parts ...string) {
-	parts = append([]string{parts[0], "-t", session.SessionId}, parts[1:]...)
+// Client abstracts access to the tmux server so that session building can be run for real,
+// printed as a dry run or recorded for tests
+type Client interface {
+	// Run executes a raw tmux command in the context of the given session
+	Run(session config.Session, args ...string) error
+	// Output executes a raw tmux command in the context of the given session and returns its stdout
+	Output(session config.Session, args ...string) (string, error)
+	// SessionExists checks if there is already a tmux session with the provided session id/name
+	SessionExists(session config.Session) bool
+	// AwaitSession waits for the tmux session to become available before we start trying to manipulate it
+	AwaitSession(session config.Session) error
+	// Attach attaches the current terminal to the given session
+	Attach(session config.Session) error
+	// Shell runs a shell command (such as a lifecycle hook) in the sessions directory with the
+	// sessions environment variables set
+	Shell(session config.Session, command string) error
+}
 
-	if session.Debug {
-		session.L.Println("tmux ", strings.Join(parts, " "))
-		return
-	}
+// ErrSessionTimeout is returned when a session does not become available in time
+var ErrSessionTimeout = errors.New("timed out waiting for session")
 
-	c := exec.Command("tmux", parts...)
-	if session.Directory != "" {
-		c.Dir = session.Directory
+// CommandError describes a tmux command that failed to run
+type CommandError struct {
+	Command Command
+	// Stderr contains anything tmux wrote to stderr while running the command
+	Stderr string
+	Err    error
+}
+
+// Error implements error
+func (e *CommandError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("%s: %s", e.Command, e.Err)
 	}
 
-	c.Run()
+	return fmt.Sprintf("%s: %s: %s", e.Command, e.Err, e.Stderr)
 }
 
-// SessionExists checks if there is already a tmux session with the provided session id/name
-func SessionExists(session config.Session) bool {
-	if session.Debug {
-		return false
-	}
+// Unwrap allows errors.Is/As to inspect the underlying error
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
 
-	c := exec.Command("tmux", "ls")
-	out, err := c.CombinedOutput()
-	if err != nil {
-		return false
-	}
-	s := bufio.NewScanner(bytes.NewReader(out))
+// Command is a single tmux invocation
+type Command struct {
+	Args []string
+	Dir  string
+}
 
-	for s.Scan() {
-		parts := strings.Split(s.Text(), ":")
-		if len(parts) > 0 && parts[0] == session.SessionId {
-			return true
-		}
-	}
+// String implements fmt.Stringer
+func (c Command) String() string {
+	return "tmux " + strings.Join(c.Args, " ")
+}
 
-	return false
+// Cmd is an alias function to make running subsequent tmux commands simpler and more readable
+func Cmd(client Client, session config.Session, parts ...string) error {
+	parts = append([]string{parts[0], "-t", session.SessionId}, parts[1:]...)
+
+	return client.Run(session, parts...)
+}
+
+// newCommand builds the Command that will be run against the server for the given session
+func newCommand(session config.Session, args ...string) Command {
+	return Command{Args: append(ServerArgs(session), args...)}
 }
 
-// AwaitSession waits for the tmux session to become available before we start trying to manipulate it
-func AwaitSession(session config.Session) {
-	if session.Debug {
-		return
+// ServerArgs returns the tmux flags needed to talk to the server the session lives on
+//
+// If both a socket path and name are set the path wins, matching tmux's own behaviour
+func ServerArgs(session config.Session) []string {
+	if session.SocketPath != "" {
+		return []string{"-S", session.SocketPath}
 	}
 
-	ticker := time.NewTicker(2 * time.Millisecond)
-	timeout := time.After(time.Second)
-	for {
-		select {
-		case <-timeout:
-			return
-		case <-ticker.C:
-			cmd := exec.Command("tmux", "ls")
-			data, err := cmd.CombinedOutput()
-			if err != nil {
-				continue
-			}
-
-			s := bufio.NewScanner(bytes.NewReader(data))
-			for s.Scan() {
-				if strings.HasPrefix(s.Text(), session.SessionId) {
-					return
-				}
-			}
-		}
+	if session.SocketName != "" {
+		return []string{"-L", session.SocketName}
 	}
+
+	return nil
 }
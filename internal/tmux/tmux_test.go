@@ -3,6 +3,7 @@ package tmux
 import (
 	"bytes"
 	"log"
+	"os"
 	"os/exec"
 	"testing"
 	"time"
@@ -18,29 +19,49 @@ func TestCmd(t *testing.T) {
 	cmd := exec.Command("tmux", "new-session", "-d", "-s", s.SessionId)
 	require.Nil(t, cmd.Run())
 
+	client := NewExecClient()
+
 	time.Sleep(20 * time.Millisecond)
-	require.True(t, SessionExists(s))
+	require.True(t, client.SessionExists(s))
 
-	Cmd(s, "kill-session")
-	assert.False(t, SessionExists(s))
+	Cmd(client, s, "kill-session")
+	assert.False(t, client.SessionExists(s))
 }
 
 func TestBadCmd(t *testing.T) {
 	s := config.Session{SessionId: "automux-test-session"}
-	Cmd(s, "bad session")
+	client := NewExecClient()
+	err := Cmd(client, s, "bad session")
 
-	assert.False(t, SessionExists(s))
+	var cmdErr *CommandError
+	require.ErrorAs(t, err, &cmdErr)
+	assert.NotEmpty(t, cmdErr.Stderr)
+	assert.False(t, client.SessionExists(s))
 }
 
-func TestCmdDebug(t *testing.T) {
+func TestCmdDryRun(t *testing.T) {
 	var (
 		b bytes.Buffer
 		l = log.New(&b, "", 0)
-		s = config.Session{SessionId: "automux-test-session", Debug: true, L: l}
+		s = config.Session{SessionId: "automux-test-session"}
 	)
-	Cmd(s, "new-session")
+	Cmd(NewDryRunClient(l), s, "new-session")
 
-	assert.Equal(t, "tmux  new-session -t automux-test-session\n", b.String())
+	assert.Equal(t, "tmux new-session -t automux-test-session\n", b.String())
+}
+
+func TestCmdRecorder(t *testing.T) {
+	var (
+		s   = config.Session{SessionId: "automux-test-session", Directory: "../"}
+		rec = NewRecorder()
+	)
+	Cmd(rec, s, "rename-window", "Editor")
+	Cmd(rec, s, "send-keys", "nvim", "Enter")
+
+	assert.Equal(t, []Command{
+		{Args: []string{"rename-window", "-t", "automux-test-session", "Editor"}, Dir: "../"},
+		{Args: []string{"send-keys", "-t", "automux-test-session", "nvim", "Enter"}, Dir: "../"},
+	}, rec.Commands)
 }
 
 var sessionExistsChecks = []struct {
@@ -61,7 +82,7 @@ func TestSessionExists(t *testing.T) {
 	for _, check := range sessionExistsChecks {
 		t.Run(check.id, func(t *testing.T) {
 			s.SessionId = check.id
-			require.Equal(t, check.expected, SessionExists(s), "session existis")
+			require.Equal(t, check.expected, NewExecClient().SessionExists(s), "session existis")
 		})
 	}
 
@@ -69,10 +90,10 @@ func TestSessionExists(t *testing.T) {
 	require.Nil(t, c.Run(), "kill session")
 }
 
-// TestSessionExistsDebug checks both paths for session existance in debug mode
-// in debug mode it should always be false
-func TestSessionExistsDbeug(t *testing.T) {
-	s := config.Session{SessionId: "automux-test-session", Debug: true}
+// TestSessionExistsDryRun checks both paths for session existance in dry run mode
+// in dry run mode it should always be false
+func TestSessionExistsDryRun(t *testing.T) {
+	s := config.Session{SessionId: "automux-test-session"}
 
 	c := exec.Command("tmux", "new-session", "-d", "-s", s.SessionId)
 	require.Nil(t, c.Run(), "setup session")
@@ -80,7 +101,7 @@ func TestSessionExistsDbeug(t *testing.T) {
 	for _, check := range sessionExistsChecks {
 		t.Run(check.id, func(t *testing.T) {
 			s.SessionId = check.id
-			require.False(t, SessionExists(s), "session existis")
+			require.False(t, NewDryRunClient(nil).SessionExists(s), "session existis")
 		})
 	}
 
@@ -97,7 +118,7 @@ func TestAwaitSession(t *testing.T) {
 	require.Nil(t, c.Run(), "setup session")
 
 	start := time.Now()
-	AwaitSession(s)
+	require.Nil(t, NewExecClient().AwaitSession(s))
 	assert.WithinDuration(t, start, time.Now(), time.Second, "found session")
 
 	c = exec.Command("tmux", "kill-session", "-t", s.SessionId)
@@ -109,17 +130,95 @@ func TestAwaitSessionTimeout(t *testing.T) {
 	s := config.Session{SessionId: "automux-test-session"}
 
 	start := time.Now()
-	AwaitSession(s)
+	err := NewExecClient().AwaitSession(s)
 
+	assert.ErrorIs(t, err, ErrSessionTimeout)
 	assert.WithinDuration(t, start, time.Now(), time.Millisecond*1015, "times out soon after a seccond")
 }
 
-// TestAwaitSessionDebug checks that AwaitSession does not actully wait for a session in debug mode
-func TestAwaitSessionDebug(t *testing.T) {
-	s := config.Session{SessionId: "automux-test-session", Debug: true}
+// TestAwaitSessionDryRun checks that AwaitSession does not actully wait for a session in dry run mode
+func TestAwaitSessionDryRun(t *testing.T) {
+	s := config.Session{SessionId: "automux-test-session"}
 
 	start := time.Now()
-	AwaitSession(s)
+	NewDryRunClient(nil).AwaitSession(s)
 
 	assert.WithinDuration(t, start, time.Now(), time.Millisecond*10, "times out soon after a seccond")
 }
+
+// TestListSessions checks sessions are listed from the selected server and that a server
+// that is not running is treated as having no sessions
+func TestListSessions(t *testing.T) {
+	s := config.Session{SessionId: "automux-test-list", SocketName: "automux-test-list", Directory: "/tmp"}
+	client := NewExecClient()
+
+	sessions, err := ListSessions(client, s)
+	require.Nil(t, err)
+	require.Empty(t, sessions)
+
+	require.Nil(t, client.Run(s, "new-session", "-d", "-s", s.SessionId, "-c", "/tmp"))
+	defer client.Run(s, "kill-server")
+
+	sessions, err = ListSessions(client, s)
+	require.Nil(t, err)
+	require.Equal(t, []SessionInfo{{Name: s.SessionId, Windows: 1, Path: "/tmp"}}, sessions)
+}
+
+func TestListWindows(t *testing.T) {
+	s := config.Session{SessionId: "automux-test-windows", SocketName: "automux-test-windows", Directory: "/tmp"}
+	client := NewExecClient()
+
+	require.Nil(t, client.Run(s, "-f", "/dev/null", "new-session", "-d", "-s", s.SessionId, "-x", "80", "-y", "24"))
+	defer client.Run(s, "kill-server")
+	require.Nil(t, client.Run(s, "new-window", "-t", s.SessionId+":", "-n", "second window"))
+
+	windows, err := ListWindows(client, s)
+	require.Nil(t, err)
+	require.Len(t, windows, 2)
+	require.Equal(t, "second window", windows[1].Name)
+	require.True(t, windows[1].Active)
+	require.False(t, windows[0].Active)
+	require.Equal(t, 80, windows[0].Width)
+
+	panes, err := ListPanes(client, s)
+	require.Nil(t, err)
+	require.Len(t, panes, 2)
+	require.Equal(t, "second window", panes[1].WindowName)
+	require.Equal(t, 0, panes[1].Left)
+}
+
+// TestListPlainLocale checks that the list output is still parsed when tmux is run outside of tmux
+// with a locale that is not UTF-8
+func TestListPlainLocale(t *testing.T) {
+	t_plainLocale(t)
+
+	s := config.Session{SessionId: "automux-test-locale", SocketName: "automux-test-locale", Directory: "/tmp"}
+	client := NewExecClient()
+
+	require.Nil(t, client.Run(s, "-f", "/dev/null", "new-session", "-d", "-s", s.SessionId, "-n", "editor", "-c", "/tmp"))
+	defer client.Run(s, "kill-server")
+
+	sessions, err := ListSessions(client, s)
+	require.Nil(t, err)
+	require.Equal(t, []SessionInfo{{Name: s.SessionId, Windows: 1, Path: "/tmp"}}, sessions)
+
+	panes, err := ListPanes(client, s)
+	require.Nil(t, err)
+	require.Len(t, panes, 1)
+	require.Equal(t, "editor", panes[0].WindowName)
+
+	window, pane, err := ActivePane(client, s)
+	require.Nil(t, err)
+	require.Equal(t, 0, window)
+	require.Equal(t, 0, pane)
+}
+
+// t_plainLocale runs the test as if automux was started outside of tmux with the C locale
+func t_plainLocale(t *testing.T) {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		t.Setenv(key, "C")
+	}
+
+	t.Setenv("TMUX", "")
+	os.Unsetenv("TMUX")
+}
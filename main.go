@@ -16,7 +16,7 @@ func main() {
 	ctx := context.WithValue(context.Background(), "logger", l)
 
 	root := cmd.Trigger()
-	root.AddCommand(cmd.Init(), cmd.PrintName())
+	root.AddCommand(cmd.Init(), cmd.PrintName(), cmd.Pick(), cmd.Ls(), cmd.Kill(), cmd.Restart(), cmd.Sync(), cmd.Export(), cmd.Validate(), cmd.Schema(), cmd.Convert())
 
 	if err := root.ExecuteContext(ctx); err != nil {
 		log.Fatal(err)